@@ -1,16 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/aglili/auction-app/internal/config"
 	"github.com/aglili/auction-app/internal/provider"
 	"github.com/aglili/auction-app/internal/routes"
 )
 
+// how long in-flight requests get to finish once shutdown starts
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg := config.LoadConfig()
 
 	db, err := config.ConnectToDB(cfg)
@@ -25,7 +37,13 @@ func main() {
 	}
 	defer redis.Close()
 
-	prov := provider.NewProvider(cfg, db, redis)
+	prov, err := provider.NewProvider(cfg, db, redis)
+	if err != nil {
+		log.Fatalf("failed to set up the app : %v", err)
+	}
+	if err := prov.Run(ctx); err != nil {
+		log.Fatalf("failed to start background workers: %v", err)
+	}
 
 	routes := routes.SetupRoutes(prov)
 
@@ -34,8 +52,31 @@ func main() {
 		Handler: routes,
 	}
 
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("failed to start server: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		log.Printf("failed to start server: %v", err)
+		stop()
+	case <-ctx.Done():
 	}
 
+	log.Println("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// stops accepting connections and waits for in-flight requests
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("failed to shut down server: %v", err)
+	}
+
+	prov.Wait()
+
+	log.Println("shutdown complete")
 }
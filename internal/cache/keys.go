@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HighestBidKey holds the current highest bid of an auction in minor units.
+// Amounts used to be stored as decimals under keys without the _minor
+// suffix; those are ignored and the state is rebuilt from Postgres.
+func HighestBidKey(auctionID uuid.UUID) string {
+	return fmt.Sprintf("auction:%s:highest_bid_minor", auctionID.String())
+}
+
+// HighestBidderKey holds the user ID of the current leader of an auction.
+func HighestBidderKey(auctionID uuid.UUID) string {
+	return fmt.Sprintf("auction:%s:highest_bidder", auctionID.String())
+}
+
+// ProxyBidsKey is a hash of bidder ID to the maximum amount their proxy may
+// bid, in minor units.
+func ProxyBidsKey(auctionID uuid.UUID) string {
+	return fmt.Sprintf("auction:%s:proxy_bids_minor", auctionID.String())
+}
+
+// ClosedKey marks an auction as closed so bids already in flight are
+// rejected after the rest of its bidding state has been cleared.
+func ClosedKey(auctionID uuid.UUID) string {
+	return fmt.Sprintf("auction:%s:closed", auctionID.String())
+}
+
+// ClosedMarkerTTL is how long ClosedKey is kept after an auction closes.
+const ClosedMarkerTTL = time.Hour
+
+// ClosingQueueKey is a sorted set of open auctions scored by end time.
+const ClosingQueueKey = "auctions:closing"
+
+// AuctionKeys lists every key holding live bidding state for an auction.
+func AuctionKeys(auctionID uuid.UUID) []string {
+	return []string{
+		HighestBidKey(auctionID),
+		HighestBidderKey(auctionID),
+		ProxyBidsKey(auctionID),
+	}
+}
+
+// IdempotencyKey holds the outcome of a request a user sent with an
+// Idempotency-Key header.
+func IdempotencyKey(userID, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", userID, key)
+}
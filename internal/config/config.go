@@ -2,7 +2,11 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aglili/auction-app/pkg/constants"
 	"github.com/joho/godotenv"
 )
 
@@ -16,7 +20,32 @@ type Config struct {
 	AppEnv            string
 	SecretKey         string
 	RedisURL          string
+	AppBaseURL        string
 	PaystackSecretKey string
+	AdminAPIKey       string
+
+	// "paystack" or "fake", which takes payments in memory for local runs
+	PaymentProvider string
+
+	// replicas share a consumer group so each event is handled once; the
+	// consumer name must be stable across restarts of the same replica
+	EventConsumerGroup string
+	EventConsumerName  string
+	// most events of a single channel handled at once by this replica
+	EventWorkers int
+
+	// "redis" validates bids against the cache with a Lua script, "postgres" places
+	// them in a single transaction on the auction row
+	BidPlacementMode string
+
+	// how long a winner has to pay before the auction goes to the next bidder
+	PaymentWindow time.Duration
+
+	// how long responses to requests with an Idempotency-Key are kept
+	IdempotencyKeyTTL time.Duration
+
+	// buy-now is withdrawn once the highest bid passes this percentage of the buy-now price
+	BuyNowDisablePercent int64
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -27,19 +56,44 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return value
 }
 
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func LoadConfig() *Config {
 	_ = godotenv.Load()
 
+	hostname, _ := os.Hostname()
+	appPort := getEnvOrDefault("APP_PORT", ":6000")
+
 	return &Config{
 		DbName:            getEnvOrDefault("DB_NAME", ""),
 		DbHost:            getEnvOrDefault("DB_HOST", ""),
 		DbUser:            getEnvOrDefault("DB_USER", ""),
 		DbPass:            getEnvOrDefault("DB_PASSWORD", ""),
 		DbPort:            getEnvOrDefault("DB_PORT", ""),
-		AppPort:           getEnvOrDefault("APP_PORT", ":6000"),
+		AppPort:           appPort,
+		AppBaseURL:        getEnvOrDefault("APP_BASE_URL", "http://localhost:"+strings.TrimPrefix(appPort, ":")),
 		AppEnv:            getEnvOrDefault("APP_ENV", "production"),
 		SecretKey:         getEnvOrDefault("SECRET_KEY", "default_key_trial"),
 		RedisURL:          getEnvOrDefault("REDIS_URL", ""),
 		PaystackSecretKey: getEnvOrDefault("PAYSTACK_SECRET_KEY", ""),
+		AdminAPIKey:       getEnvOrDefault("ADMIN_API_KEY", ""),
+
+		PaymentProvider: getEnvOrDefault("PAYMENT_PROVIDER", constants.PAYMENT_PROVIDER_PAYSTACK),
+
+		EventConsumerGroup: getEnvOrDefault("EVENT_CONSUMER_GROUP", "auction-app"),
+		EventConsumerName:  getEnvOrDefault("EVENT_CONSUMER_NAME", hostname),
+		EventWorkers:       getEnvIntOrDefault("EVENT_WORKERS", 4),
+
+		BidPlacementMode:     getEnvOrDefault("BID_PLACEMENT_MODE", constants.BID_PLACEMENT_REDIS),
+		BuyNowDisablePercent: int64(getEnvIntOrDefault("BUY_NOW_DISABLE_PERCENT", 50)),
+
+		PaymentWindow:     time.Duration(getEnvIntOrDefault("PAYMENT_WINDOW_HOURS", 48)) * time.Hour,
+		IdempotencyKeyTTL: time.Duration(getEnvIntOrDefault("IDEMPOTENCY_KEY_TTL_HOURS", 24)) * time.Hour,
 	}
 }
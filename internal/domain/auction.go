@@ -7,6 +7,16 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	AuctionStatusOpen          = "open"
+	AuctionStatusClosing       = "closing"
+	AuctionStatusClosed        = "closed"
+	AuctionStatusPaid          = "paid"
+	AuctionStatusUnpaid        = "unpaid"
+	AuctionStatusCancelled     = "cancelled"
+	AuctionStatusReserveNotMet = "reserve_not_met"
+)
+
 type AuctionImage struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	AuctionID uuid.UUID `json:"auction_id" db:"auction_id"`
@@ -15,41 +25,143 @@ type AuctionImage struct {
 }
 
 type Auction struct {
-	ID            uuid.UUID `json:"id" db:"id"`
-	SellerID      uuid.UUID `json:"seller_id,omitempty" db:"seller_id"`
-	Title         string    `json:"title" db:"title"`
-	Description   *string   `json:"description,omitempty" db:"description"`
-	StartingPrice float64   `json:"starting_price" db:"starting_price"`
-	CurrentPrice  float64   `json:"current_price" db:"current_price"`
-	Status        string    `json:"status" db:"status"` // open || closed || cancelled
-	StartTime     time.Time `json:"start_time" db:"start_time"`
-	EndTime       time.Time `json:"end_time" db:"end_time"`
-	CreatedAt     time.Time `json:"created_at" db:"created_at"`
-	Images        []string  `json:"images,omitempty" db:"-"`
+	ID                 uuid.UUID `json:"id" db:"id"`
+	SellerID           uuid.UUID `json:"seller_id,omitempty" db:"seller_id"`
+	Title              string    `json:"title" db:"title"`
+	Description        *string   `json:"description,omitempty" db:"description"`
+	Currency           string    `json:"currency" db:"currency"`
+	StartingPrice      Amount    `json:"starting_price" db:"starting_price"`
+	CurrentPrice       Amount    `json:"current_price" db:"current_price"`
+	BidIncrement       *Amount   `json:"bid_increment,omitempty" db:"bid_increment"` // nil uses DefaultIncrementTiers
+	ReservePrice       *Amount   `json:"-" db:"reserve_price"`                       // hidden from bidders, nil when there is no reserve
+	BuyNowPrice        *Amount   `json:"buy_now_price,omitempty" db:"buy_now_price"`
+	SoftCloseWindow    int       `json:"soft_close_window_minutes" db:"soft_close_window_minutes"`
+	SoftCloseExtension int       `json:"soft_close_extension_minutes" db:"soft_close_extension_minutes"`
+	Status             string    `json:"status" db:"status"` // open || closing || closed || paid || unpaid || cancelled || reserve_not_met
+	StartTime          time.Time `json:"start_time" db:"start_time"`
+	EndTime            time.Time `json:"end_time" db:"end_time"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+	Images             []string  `json:"images,omitempty" db:"-"`
+	ClosingToken       uuid.UUID `json:"-" db:"closing_token"` // identifies the caller's claim, set only by the claim methods
 }
 
 type AuctionResponse struct {
-	ID            uuid.UUID `json:"id"`
-	Title         string    `json:"title"`
-	Description   *string   `json:"description,omitempty"`
-	StartingPrice float64   `json:"starting_price"`
-	CurrentPrice  float64   `json:"current_price"`
-	Status        string    `json:"status"`
-	StartTime     time.Time `json:"start_time"`
-	EndTime       time.Time `json:"end_time"`
-	Images        []string  `json:"images,omitempty"`
+	ID                 uuid.UUID `json:"id"`
+	Title              string    `json:"title"`
+	Description        *string   `json:"description,omitempty"`
+	Currency           string    `json:"currency"`
+	StartingPrice      Amount    `json:"starting_price"`
+	CurrentPrice       Amount    `json:"current_price"`
+	BidIncrement       *Amount   `json:"bid_increment,omitempty"`
+	MinimumBid         Amount    `json:"minimum_bid"`
+	ReserveMet         bool      `json:"reserve_met"`
+	BuyNowPrice        *Amount   `json:"buy_now_price,omitempty"`
+	SoftCloseWindow    int       `json:"soft_close_window_minutes"`
+	SoftCloseExtension int       `json:"soft_close_extension_minutes"`
+	Status             string    `json:"status"`
+	StartTime          time.Time `json:"start_time"`
+	EndTime            time.Time `json:"end_time"`
+	Images             []string  `json:"images,omitempty"`
+}
+
+// IncrementTier is the minimum raise over the current price for prices of
+// From and above.
+type IncrementTier struct {
+	From      Amount
+	Increment Amount
+}
+
+// DefaultIncrementTiers apply to auctions without their own bid increment.
+var DefaultIncrementTiers = []IncrementTier{
+	{From: 0, Increment: 5},
+	{From: 100, Increment: 25},
+	{From: 500, Increment: 50},
+	{From: 2500, Increment: 100},
+	{From: 10000, Increment: 250},
+	{From: 25000, Increment: 500},
+	{From: 50000, Increment: 1000},
+	{From: 100000, Increment: 2500},
+	{From: 250000, Increment: 5000},
+	{From: 500000, Increment: 10000},
+}
+
+// IncrementTiers lists the increment rules of the auction. A fixed bid
+// increment is a single tier covering every price.
+func (a *Auction) IncrementTiers() []IncrementTier {
+	if a.BidIncrement != nil {
+		return []IncrementTier{{From: 0, Increment: *a.BidIncrement}}
+	}
+	return DefaultIncrementTiers
+}
+
+// MinimumIncrement is the smallest amount a bid must raise price by.
+func (a *Auction) MinimumIncrement(price Amount) Amount {
+	tiers := a.IncrementTiers()
+
+	increment := tiers[0].Increment
+	for _, tier := range tiers {
+		if price >= tier.From {
+			increment = tier.Increment
+		}
+	}
+	return increment
+}
+
+// MinimumBid is the lowest amount the next bid on the auction may be.
+func (a *Auction) MinimumBid() Amount {
+	return a.CurrentPrice + a.MinimumIncrement(a.CurrentPrice)
+}
+
+// Money gives an amount in the currency of the auction.
+func (a *Auction) Money(amount Amount) Money {
+	return Money{Amount: amount, Currency: a.Currency}
+}
+
+// ReserveMet reports whether price is enough to sell the auction.
+func (a *Auction) ReserveMet(price Amount) bool {
+	return a.ReservePrice == nil || price >= *a.ReservePrice
+}
+
+// SoftCloseEndTime gives the end time after a bid placed at bidTime. Bids in
+// the last SoftCloseWindow minutes push the end back by SoftCloseExtension
+// minutes so a last-second bid can still be answered.
+func (a *Auction) SoftCloseEndTime(bidTime time.Time) (time.Time, bool) {
+	if a.SoftCloseWindow <= 0 || a.SoftCloseExtension <= 0 {
+		return a.EndTime, false
+	}
+
+	window := time.Duration(a.SoftCloseWindow) * time.Minute
+	if bidTime.Before(a.EndTime.Add(-window)) {
+		return a.EndTime, false
+	}
+
+	return a.EndTime.Add(time.Duration(a.SoftCloseExtension) * time.Minute), true
 }
 
 type AuctionRepository interface {
 	CreateAuction(ctx context.Context, auction *Auction, sellerID uuid.UUID, imageURLs []string) (*Auction, error)
 	GetAuction(ctx context.Context, auctionID uuid.UUID) (*Auction, error)
+	GetAuctionsByIDs(ctx context.Context, auctionIDs []uuid.UUID) ([]*Auction, error)
 	GetUserAuctions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*Auction, int, error)
-	UpdateCurrentPrice(ctx context.Context, auctionID uuid.UUID, amount float64) error
+	UpdateCurrentPrice(ctx context.Context, auctionID uuid.UUID, amount Amount) error
+	SyncCurrentPrice(ctx context.Context, auctionID uuid.UUID) (bool, error)
 	CloseAuction(ctx context.Context, auctionID uuid.UUID) error
-	GetEndedActiveAuctions(ctx context.Context, currentTime time.Time) ([]*Auction, error)
+	UpdateAuctionStatus(ctx context.Context, auctionID uuid.UUID, status string) error
+	CloseWithSale(ctx context.Context, auctionID, buyerID uuid.UUID, price Amount, now time.Time, outbox ...*OutboxMessage) error
+	ExtendEndTime(ctx context.Context, auctionID uuid.UUID, endTime time.Time, outbox ...*OutboxMessage) (bool, error)
+	ClaimEndedAuction(ctx context.Context, auctionID uuid.UUID, currentTime time.Time, lease time.Duration) (*Auction, error)
+	ClaimEndedAuctions(ctx context.Context, currentTime time.Time, lease time.Duration, limit int) ([]*Auction, error)
+	CompleteClosing(ctx context.Context, auctionID, closingToken uuid.UUID, status string, outbox ...*OutboxMessage) error
+	GetActiveAuctions(ctx context.Context) ([]*Auction, error)
 	GetOpenAuctions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*Auction, int, error)
 }
 
+// AuctionQueue tracks when open auctions are due to close.
+type AuctionQueue interface {
+	Schedule(ctx context.Context, auctionID uuid.UUID, endTime time.Time) error
+	Unschedule(ctx context.Context, auctionID uuid.UUID) error
+}
+
 type AuctionService interface {
 	CreateAuction(ctx context.Context, auction *Auction, sellerID uuid.UUID, imageURLs []string) (*Auction, error)
 	GetAuction(ctx context.Context, auctionID uuid.UUID) (*Auction, error)
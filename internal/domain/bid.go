@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -9,14 +10,91 @@ import (
 type Bid struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	AuctionID uuid.UUID `json:"auction_id" db:"auction_id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Amount    float64   `json:"amount" db:"amount"`
+	UserID    uuid.UUID `json:"user_id" db:"bidder_id"`
+	Amount    Amount    `json:"amount" db:"amount"`
+	IsAuto    bool      `json:"is_auto" db:"is_auto"` // placed by a proxy on the bidder's behalf
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ProxyBid is the ceiling up to which the system bids for a user on an auction.
+type ProxyBid struct {
+	AuctionID uuid.UUID `json:"auction_id" db:"auction_id"`
+	UserID    uuid.UUID `json:"user_id" db:"bidder_id"`
+	MaxAmount Amount    `json:"max_amount" db:"max_amount"`
+}
+
+// BidPlacement is everything a bid request stores: the bids it placed, the
+// bidder's proxy ceiling, the price it leaves the auction at and the events
+// it raises.
+type BidPlacement struct {
+	Bids   []*Bid
+	Proxy  *ProxyBid
+	Price  Amount
+	Outbox []*OutboxMessage
+}
+
+type BidResult struct {
+	AuctionID    uuid.UUID `json:"auction_id"`
+	CurrentPrice Amount    `json:"current_price"`
+	IsLeading    bool      `json:"is_leading"`
+	MaxAmount    Amount    `json:"max_amount,omitempty"`
+	EndTime      time.Time `json:"end_time"`
+}
+
+// BidCursor marks the last bid of a page in an auction's bid history.
+// Bids are ordered by amount, then created_at, then id, all descending.
+type BidCursor struct {
+	Amount    Amount    `json:"a"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+type BidHistoryEntry struct {
+	Amount    Amount    `json:"amount"`
+	Bidder    string    `json:"bidder"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserBid is a user's bidding activity on a single auction.
+type UserBid struct {
+	AuctionID  uuid.UUID
+	HighestBid Amount
+	IsLeading  bool
+	LastBidAt  time.Time
+}
+
+type UserBidSummary struct {
+	AuctionID     uuid.UUID `json:"auction_id"`
+	Title         string    `json:"title"`
+	HighestBid    Amount    `json:"highest_bid"`
+	CurrentPrice  Amount    `json:"current_price"`
+	IsLeading     bool      `json:"is_leading"`
+	AuctionStatus string    `json:"auction_status"`
+	BidStatus     string    `json:"bid_status"` // winning || outbid || won || lost || unpaid || reserve_not_met || cancelled
+	EndTime       time.Time `json:"end_time"`
+	LastBidAt     time.Time `json:"last_bid_at"`
 }
 
 type BidRepository interface {
-	CreateBid(ctx context.Context, auctionID, userID uuid.UUID, amount float64) error
+	CreateBids(ctx context.Context, bids []*Bid, proxy *ProxyBid, outbox ...*OutboxMessage) error
+	GetAuctionBids(ctx context.Context, auctionID uuid.UUID, cursor *BidCursor, limit int) ([]*Bid, error)
+	GetUserBids(ctx context.Context, userID uuid.UUID, page, limit int) ([]*UserBid, int, error)
+	PlaceBids(ctx context.Context, auctionID uuid.UUID, amount Amount, now time.Time, resolve func(highest *Bid, proxies []*ProxyBid) (*BidPlacement, error)) error
+	GetHighestBid(ctx context.Context, auctionID uuid.UUID) (*Bid, error)
+	GetProxyBids(ctx context.Context, auctionID uuid.UUID, amount Amount) ([]*ProxyBid, error)
+	GetNextBidder(ctx context.Context, auctionID uuid.UUID) (*Bid, error)
 }
 
 type BidService interface {
-	CreateBid(ctx context.Context, auctionID, userID uuid.UUID, amount float64) error
+	CreateBid(ctx context.Context, auctionID, userID uuid.UUID, amount, maxAmount Amount) (*BidResult, error)
+	GetAuctionBids(ctx context.Context, auctionID uuid.UUID, cursor string, limit int) ([]*BidHistoryEntry, string, error)
+	GetUserBids(ctx context.Context, userID uuid.UUID, page, limit int) ([]*UserBidSummary, int, error)
+	BuyNow(ctx context.Context, auctionID, userID uuid.UUID) (*BidResult, error)
+}
+
+// BiddingStateCache keeps the cached bidding state of open auctions in line
+// with the bids table, which is the source of truth.
+type BiddingStateCache interface {
+	WarmBiddingState(ctx context.Context) error
+	ReconcileBiddingState(ctx context.Context) error
 }
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetter is an event whose handler kept failing after every retry.
+type DeadLetter struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	EventID    string     `json:"event_id" db:"event_id"`
+	Channel    string     `json:"channel" db:"channel"`
+	Payload    string     `json:"payload" db:"payload"`
+	Error      string     `json:"error" db:"error"`
+	Attempts   int        `json:"attempts" db:"attempts"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ReplayedAt *time.Time `json:"replayed_at,omitempty" db:"replayed_at"`
+}
+
+type DeadLetterRepository interface {
+	CreateDeadLetter(ctx context.Context, deadLetter *DeadLetter) error
+	GetDeadLetters(ctx context.Context, page, limit int) ([]*DeadLetter, int, error)
+	GetDeadLetter(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+	MarkReplayed(ctx context.Context, id uuid.UUID) error
+}
+
+type DeadLetterService interface {
+	GetDeadLetters(ctx context.Context, page, limit int) ([]*DeadLetter, int, error)
+	GetDeadLetter(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id uuid.UUID) (*DeadLetter, error)
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	CurrencyGHS = "GHS"
+	CurrencyNGN = "NGN"
+	CurrencyKES = "KES"
+	CurrencyZAR = "ZAR"
+	CurrencyUSD = "USD"
+
+	// DefaultCurrency is used for auctions created without a currency
+	DefaultCurrency = CurrencyGHS
+)
+
+// SupportedCurrencies are the currencies auctions can be held in. Each has
+// 100 minor units to the major unit.
+var SupportedCurrencies = []string{CurrencyGHS, CurrencyNGN, CurrencyKES, CurrencyZAR, CurrencyUSD}
+
+// minorUnits is how many minor units make up one major unit
+const minorUnits = 100
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Amount is a sum of money in the minor unit of its currency, such as
+// pesewas or kobo. It is written as a decimal of major units in JSON, so
+// 15075 is sent and received as 150.75.
+type Amount int64
+
+// ParseAmount parses a decimal number of major units, such as "150.75",
+// without going through floating point. More than two decimal places is an
+// error rather than being rounded.
+func ParseAmount(s string) (Amount, error) {
+	digits, negative := strings.CutPrefix(s, "-")
+
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+	if !isDigits(whole) || (hasFraction && !isDigits(fraction)) || len(fraction) > 2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	units, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", 2-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if negative {
+		units = -units
+	}
+	return Amount(units), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal of major units, such as "150.75".
+func (a Amount) String() string {
+	sign := ""
+	units := uint64(a)
+	if a < 0 {
+		sign = "-"
+		units = uint64(-a)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units/minorUnits, units%minorUnits)
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a number, or a string holding one, of major units.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	amount, err := ParseAmount(s)
+	if err != nil {
+		return err
+	}
+
+	*a = amount
+	return nil
+}
+
+// Money is an amount in a particular currency.
+type Money struct {
+	Amount   Amount `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// String formats the money for people to read, such as "GHS 150.75".
+func (m Money) String() string {
+	return m.Currency + " " + m.Amount.String()
+}
+
+// IsSupportedCurrency reports whether auctions can be held in currency.
+func IsSupportedCurrency(currency string) bool {
+	return slices.Contains(SupportedCurrencies, currency)
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+	}{
+		{"150.75", 15075},
+		{"150", 15000},
+		{"150.7", 15070},
+		{"0.05", 5},
+		{"0", 0},
+		{"007.10", 710},
+		{"-1.50", -150},
+		{"-0.5", -50},
+		{"92233720368547758.07", math.MaxInt64},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) returned %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmountRejects(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"1.234",
+		"0.001",
+		"1.",
+		".5",
+		"-",
+		"--1",
+		"+1",
+		"1e3",
+		"1,000",
+		" 1",
+		"abc",
+		"92233720368547758.08",
+		"-92233720368547758.09",
+	} {
+		if got, err := ParseAmount(in); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseAmount(%q) = %d, %v, want ErrInvalidAmount", in, got, err)
+		}
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	tests := []struct {
+		in   Amount
+		want string
+	}{
+		{15075, "150.75"},
+		{15000, "150.00"},
+		{5, "0.05"},
+		{0, "0.00"},
+		{-150, "-1.50"},
+		{math.MaxInt64, "92233720368547758.07"},
+		{math.MinInt64, "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Amount(%d).String() = %q, want %q", int64(tt.in), got, tt.want)
+		}
+	}
+}
+
+func TestAmountUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Amount
+	}{
+		{`150.75`, 15075},
+		{`"150.75"`, 15075},
+		{`150`, 15000},
+		{`"150"`, 15000},
+		{`-2.5`, -250},
+		{`null`, 42}, // left as it was
+	}
+	for _, tt := range tests {
+		got := Amount(42)
+		if err := json.Unmarshal([]byte(tt.in), &got); err != nil {
+			t.Errorf("unmarshalling %s returned %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("unmarshalling %s gave %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAmountUnmarshalJSONRejects(t *testing.T) {
+	for _, in := range []string{`150.755`, `"150.755"`, `1e2`, `"abc"`, `""`, `true`, `92233720368547758.08`} {
+		var got Amount
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("unmarshalling %s gave %d, want an error", in, got)
+		}
+	}
+}
+
+func TestAmountJSONRoundTrip(t *testing.T) {
+	for _, amount := range []Amount{0, 5, 15075, -150, math.MaxInt64, math.MinInt64 + 1} {
+		data, err := json.Marshal(Money{Amount: amount, Currency: CurrencyGHS})
+		if err != nil {
+			t.Fatalf("marshalling %d: %v", int64(amount), err)
+		}
+
+		var got Money
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("unmarshalling %s: %v", data, err)
+		}
+		if got.Amount != amount || got.Currency != CurrencyGHS {
+			t.Errorf("%d came back from %s as %+v", int64(amount), data, got)
+		}
+	}
+
+	data, _ := json.Marshal(Money{Amount: 15075, Currency: CurrencyGHS})
+	if want := `{"amount":150.75,"currency":"GHS"}`; string(data) != want {
+		t.Errorf("marshalled %s, want %s", data, want)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	if got := (Money{Amount: 15075, Currency: CurrencyNGN}).String(); got != "NGN 150.75" {
+		t.Errorf("String() = %q, want %q", got, "NGN 150.75")
+	}
+}
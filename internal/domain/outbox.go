@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxMessage is an event stored in the same transaction as the state
+// change that caused it, and relayed to the event bus after commit.
+type OutboxMessage struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Channel   string    `json:"channel" db:"channel"`
+	Payload   []byte    `json:"payload" db:"payload"`
+	Attempts  int       `json:"attempts" db:"attempts"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type OutboxRepository interface {
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error)
+	MarkDispatched(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error
+}
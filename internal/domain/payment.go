@@ -1,21 +1,115 @@
 package domain
 
-type PaymentRequest struct {
-	Email       string                 `json:"email"`
-	Amount      int64                  `json:"amount"`
-	Currency    string                 `json:"currency,omitempty"`
-	Reference   string                 `json:"reference,omitempty"`
-	CallbackURL string                 `json:"callback_url,omitempty"`
-	Metadata    map[string]interface{} `json:"metadata,omitempty"`
-	Channels   []string `json:"channels"`
-}
-
-type PaymentResponse struct {
-	Status  bool   `json:"status"`
-	Message string `json:"message"`
-	Data    struct {
-		AuthorizationURL string `json:"authorization_url"`
-		AccessCode       string `json:"access_code"`
-		Reference        string `json:"reference"`
-	} `json:"data"`
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentInitialization is a payment a provider has started, and where the
+// payer goes to complete it.
+type PaymentInitialization struct {
+	Reference        string `json:"reference"`
+	AuthorizationURL string `json:"authorization_url"`
+	AccessCode       string `json:"access_code,omitempty"`
+}
+
+// PaymentVerification is what a provider knows about a payment.
+type PaymentVerification struct {
+	Reference string          `json:"reference"`
+	Status    string          `json:"status"` // pending || success || failed
+	Amount    Money           `json:"amount"`
+	PaidAt    *time.Time      `json:"paid_at,omitempty"`
+	Payload   json.RawMessage `json:"-"`
+}
+
+// PaymentWebhookChargeSuccess is the webhook event for a completed charge.
+const PaymentWebhookChargeSuccess = "charge.success"
+
+// PaymentWebhookEvent is a verified notification from a payment provider.
+type PaymentWebhookEvent struct {
+	Type      string
+	Reference string
+	Amount    Money
+	Payload   json.RawMessage
+}
+
+// PaymentProvider takes payments on behalf of the app.
+type PaymentProvider interface {
+	Initialize(ctx context.Context, email string, amount Money, reference string) (*PaymentInitialization, error)
+	Verify(ctx context.Context, reference string) (*PaymentVerification, error)
+	Refund(ctx context.Context, reference string, amount Money) error
+	// VerifyWebhook checks that a webhook request came from the provider and
+	// decodes it.
+	VerifyWebhook(header http.Header, body []byte) (*PaymentWebhookEvent, error)
+}
+
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusSuccess   = "success"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusDefaulted = "defaulted"
+)
+
+// Payment is a request for the winner of an auction to pay for it.
+type Payment struct {
+	ID               uuid.UUID       `json:"id" db:"id"`
+	AuctionID        uuid.UUID       `json:"auction_id" db:"auction_id"`
+	UserID           uuid.UUID       `json:"user_id" db:"user_id"`
+	Reference        string          `json:"reference" db:"reference"`
+	Amount           Amount          `json:"amount" db:"amount"`
+	Currency         string          `json:"currency" db:"currency"`
+	Status           string          `json:"status" db:"status"` // pending || success || failed || defaulted
+	AuthorizationURL string          `json:"authorization_url" db:"authorization_url"`
+	ProviderPayload  json.RawMessage `json:"-" db:"provider_payload"`
+	PaidAt           *time.Time      `json:"paid_at,omitempty" db:"paid_at"`
+	ExpiresAt        time.Time       `json:"expires_at" db:"expires_at"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// Price is the amount asked for, in the currency of the auction.
+func (p *Payment) Price() Money {
+	return Money{Amount: p.Amount, Currency: p.Currency}
+}
+
+// PaymentStatusResponse is what the payer is told about a payment once it
+// has been checked with the provider.
+type PaymentStatusResponse struct {
+	Reference      string     `json:"reference"`
+	AuctionID      uuid.UUID  `json:"auction_id"`
+	Status         string     `json:"status"`                    // pending || success || failed || defaulted
+	ProviderStatus string     `json:"provider_status,omitempty"` // what the provider reported, when it was asked
+	AuctionStatus  string     `json:"auction_status"`
+	Amount         Amount     `json:"amount"`
+	Currency       string     `json:"currency"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+}
+
+type PaymentRepository interface {
+	CreatePayment(ctx context.Context, payment *Payment) error
+	GetPaymentByReference(ctx context.Context, reference string) (*Payment, error)
+	GetPendingPayment(ctx context.Context, auctionID, userID uuid.UUID) (*Payment, error)
+	CompletePayment(ctx context.Context, reference string, payload []byte, outbox ...*OutboxMessage) error
+	FailPayment(ctx context.Context, reference string, payload []byte) error
+	ClaimRefund(ctx context.Context, reference string) error
+	ReleaseRefund(ctx context.Context, reference string) error
+	GetOverduePayments(ctx context.Context, currentTime time.Time, limit int) ([]*Payment, error)
+	DefaultPayment(ctx context.Context, reference string, outbox ...*OutboxMessage) error
+}
+
+type PaymentService interface {
+	StartPayment(ctx context.Context, auctionID, userID uuid.UUID, email string, price Money) (*Payment, error)
+	ConfirmPayment(ctx context.Context, reference string, amount Money, payload []byte) error
+	HandleWebhook(ctx context.Context, header http.Header, body []byte) error
+	VerifyPayment(ctx context.Context, reference string, userID uuid.UUID) (*PaymentStatusResponse, error)
+}
+
+// PaymentDeadlines enforces the time winners have to pay.
+type PaymentDeadlines interface {
+	ExpireOverduePayments(ctx context.Context) error
 }
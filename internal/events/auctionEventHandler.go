@@ -4,19 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-
-	"github.com/aglili/auction-app/internal/domain"
 )
 
+// AuctionEventEndedHandler notifies the winner of an auction. The auction
+// has already been closed by whoever published the event.
 type AuctionEventEndedHandler struct {
 	notificationService NotificationService
-	auctionRepo         domain.AuctionRepository
 }
 
-func NewAuctionEventEndedHandler(notificationService NotificationService, auctionRepo domain.AuctionRepository) *AuctionEventEndedHandler {
+func NewAuctionEventEndedHandler(notificationService NotificationService) *AuctionEventEndedHandler {
 	return &AuctionEventEndedHandler{
 		notificationService: notificationService,
-		auctionRepo:         auctionRepo,
 	}
 }
 
@@ -30,9 +28,5 @@ func (h *AuctionEventEndedHandler) Handle(ctx context.Context, data []byte) erro
 		return fmt.Errorf("failed to notify winner: %w", err)
 	}
 
-	if err := h.auctionRepo.CloseAuction(ctx, event.AuctionID); err != nil {
-		return fmt.Errorf("failed to close auction: %w", err)
-	}
-
 	return nil
 }
@@ -0,0 +1,31 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type AuctionExtendedHandler struct {
+	notificationService NotificationService
+}
+
+func NewAuctionExtendedEventHandler(notificationService NotificationService) *AuctionExtendedHandler {
+	return &AuctionExtendedHandler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *AuctionExtendedHandler) Handle(ctx context.Context, data []byte) error {
+	var event AuctionExtendedEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal auction extended event: %w", err)
+	}
+
+	// let every connected client update its countdown
+	if err := h.notificationService.NotifyAuctionExtended(ctx, event.AuctionID, event.EndTime); err != nil {
+		return fmt.Errorf("failed to broadcast new end time: %w", err)
+	}
+
+	return nil
+}
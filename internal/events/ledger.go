@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// how long a handled event is remembered; redeliveries and republished
+	// outbox messages arrive well within this
+	processedTTL = 7 * 24 * time.Hour
+	// a consumer that dies mid-handling holds the event only until its
+	// delivery can be reclaimed
+	processingTTL = reclaimIdle
+
+	ledgerProcessing = "processing"
+	ledgerProcessed  = "processed"
+)
+
+type eventState int
+
+const (
+	eventNew eventState = iota
+	eventInProgress
+	eventProcessed
+)
+
+// eventLedger records, per consumer group and channel, the events whose
+// handler has run, so a duplicate delivery never runs it twice.
+type eventLedger struct {
+	client *redis.Client
+	group  string
+}
+
+func newEventLedger(client *redis.Client, group string) *eventLedger {
+	return &eventLedger{
+		client: client,
+		group:  group,
+	}
+}
+
+func (l *eventLedger) key(channel, eventID string) string {
+	return fmt.Sprintf("events:processed:%s:%s:%s", l.group, channel, eventID)
+}
+
+// begin claims an event for handling. It returns eventNew when the caller
+// should run the handler.
+func (l *eventLedger) begin(ctx context.Context, channel, eventID string) (eventState, error) {
+	key := l.key(channel, eventID)
+
+	claimed, err := l.client.SetNX(ctx, key, ledgerProcessing, processingTTL).Result()
+	if err != nil {
+		return eventNew, err
+	}
+	if claimed {
+		return eventNew, nil
+	}
+
+	state, err := l.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		// the claim expired between the two calls; let the next delivery take it
+		return eventInProgress, nil
+	}
+	if err != nil {
+		return eventNew, err
+	}
+
+	if state == ledgerProcessed {
+		return eventProcessed, nil
+	}
+	return eventInProgress, nil
+}
+
+// finish marks an event as handled.
+func (l *eventLedger) finish(ctx context.Context, channel, eventID string) {
+	if err := l.client.Set(ctx, l.key(channel, eventID), ledgerProcessed, processedTTL).Err(); err != nil {
+		log.Printf("Error recording event %s on channel %s as processed: %v", eventID, channel, err)
+	}
+}
+
+// extend keeps the claim on an event whose handler is still being retried.
+func (l *eventLedger) extend(ctx context.Context, channel, eventID string) {
+	if err := l.client.Expire(ctx, l.key(channel, eventID), processingTTL).Err(); err != nil {
+		log.Printf("Error extending claim on event %s on channel %s: %v", eventID, channel, err)
+	}
+}
+
+// release gives up the claim on an event whose handler failed, so it can
+// be retried.
+func (l *eventLedger) release(ctx context.Context, channel, eventID string) {
+	if err := l.client.Del(ctx, l.key(channel, eventID)).Err(); err != nil {
+		log.Printf("Error releasing event %s on channel %s: %v", eventID, channel, err)
+	}
+}
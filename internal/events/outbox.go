@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	relayInterval  = time.Second
+	relayBatchSize = 100
+	// how long a relay has to publish the messages it claimed before
+	// another relay may claim them
+	relayLease = 30 * time.Second
+)
+
+// relayRetry spaces out attempts to relay a message; messages are retried
+// until they are published, so it has no attempt limit.
+var relayRetry = RetryPolicy{
+	BaseBackoff: time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+// NewOutboxMessage wraps an event to be stored in the outbox and relayed to
+// channel.
+func NewOutboxMessage(channel string, event any) (*domain.OutboxMessage, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event: %w", channel, err)
+	}
+
+	return &domain.OutboxMessage{
+		ID:      uuid.New(),
+		Channel: channel,
+		Payload: data,
+	}, nil
+}
+
+// OutboxRelay publishes committed outbox messages to the event bus. A
+// message is only marked dispatched once published, so every event is
+// delivered at least once.
+type OutboxRelay struct {
+	repo      domain.OutboxRepository
+	publisher *EventPublisher
+}
+
+func NewOutboxRelay(repo domain.OutboxRepository, publisher *EventPublisher) *OutboxRelay {
+	return &OutboxRelay{
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+func (r *OutboxRelay) Start(ctx context.Context) {
+	log.Println("Starting outbox relay")
+
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping outbox relay")
+			return
+		case <-ticker.C:
+			// finish the batch in hand so claimed messages aren't held
+			// until their lease runs out
+			r.relay(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+func (r *OutboxRelay) relay(ctx context.Context) {
+	messages, err := r.repo.ClaimPending(ctx, relayBatchSize, relayLease)
+	if err != nil {
+		log.Printf("Error fetching outbox messages: %v", err)
+		return
+	}
+
+	for _, message := range messages {
+		if err := r.publisher.Publish(ctx, message.ID.String(), message.Channel, message.Payload); err != nil {
+			log.Printf("Failed to relay outbox message %s to %s: %v", message.ID, message.Channel, err)
+
+			retryAt := time.Now().Add(relayRetry.Backoff(message.Attempts + 1))
+			if err := r.repo.MarkFailed(ctx, message.ID, err, retryAt); err != nil {
+				log.Printf("Error recording outbox failure for %s: %v", message.ID, err)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkDispatched(ctx, message.ID); err != nil {
+			log.Printf("Error marking outbox message %s dispatched: %v", message.ID, err)
+		}
+	}
+}
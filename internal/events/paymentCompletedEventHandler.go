@@ -0,0 +1,32 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// PaymentCompletedHandler tells the buyer and seller of an auction that it
+// has been paid for.
+type PaymentCompletedHandler struct {
+	notificationService NotificationService
+}
+
+func NewPaymentCompletedEventHandler(notificationService NotificationService) *PaymentCompletedHandler {
+	return &PaymentCompletedHandler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *PaymentCompletedHandler) Handle(ctx context.Context, data []byte) error {
+	var event PaymentCompletedEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal payment completed event: %w", err)
+	}
+
+	if err := h.notificationService.NotifyPaymentCompleted(ctx, event.UserID, event.AuctionID, event.Amount); err != nil {
+		return fmt.Errorf("failed to notify payment completed: %w", err)
+	}
+
+	return nil
+}
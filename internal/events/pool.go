@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// delivery is one attempt at handling an event, counting from 1.
+type delivery struct {
+	msg     redis.XMessage
+	attempt int
+}
+
+// shardedPool handles a channel's events on a fixed number of workers.
+// Events are sharded by auction, so the events of one auction are handled
+// one at a time, in the order they were dispatched. A retried event is
+// dispatched again after its backoff, behind whatever its shard has queued
+// by then.
+type shardedPool struct {
+	shards []chan delivery
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	closing bool
+	retries sync.WaitGroup
+}
+
+func newShardedPool(workers int, handle func(d delivery)) *shardedPool {
+	pool := &shardedPool{
+		shards: make([]chan delivery, max(workers, 1)),
+	}
+
+	for i := range pool.shards {
+		shard := make(chan delivery, readCount)
+		pool.shards[i] = shard
+
+		pool.wg.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			for d := range shard {
+				handle(d)
+			}
+		}()
+	}
+
+	return pool
+}
+
+// dispatch queues an event on its shard, waiting while the shard is full.
+// It reports false if ctx ends first, in which case the event stays pending.
+func (p *shardedPool) dispatch(ctx context.Context, d delivery) bool {
+	hash := fnv.New32a()
+	hash.Write([]byte(partitionKey(d.msg)))
+	shard := p.shards[hash.Sum32()%uint32(len(p.shards))]
+
+	select {
+	case shard <- d:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// later dispatches d once wait returns, waiting off the shard so a backoff
+// doesn't hold up the other auctions on it. If wait reports false, or the
+// pool is closing, d is dropped and abandon runs instead.
+func (p *shardedPool) later(ctx context.Context, d delivery, wait func() bool, abandon func()) {
+	p.mu.Lock()
+	if p.closing {
+		p.mu.Unlock()
+		abandon()
+		return
+	}
+	p.retries.Add(1)
+	p.mu.Unlock()
+
+	go func() {
+		defer p.retries.Done()
+		if !wait() || !p.dispatch(ctx, d) {
+			abandon()
+		}
+	}()
+}
+
+// close waits for the workers to handle every event already queued. No
+// events may be dispatched once it is called, and the ctx given to later
+// must already be done so pending retries give up.
+func (p *shardedPool) close() {
+	p.mu.Lock()
+	p.closing = true
+	p.mu.Unlock()
+	p.retries.Wait()
+
+	for _, shard := range p.shards {
+		close(shard)
+	}
+	p.wg.Wait()
+}
+
+// partitionKey is the auction an event belongs to. Events without one are
+// spread by their ID.
+func partitionKey(msg redis.XMessage) string {
+	data, _ := msg.Values[messageDataField].(string)
+
+	var event struct {
+		AuctionID string `json:"auction_id"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err == nil && event.AuctionID != "" {
+		return event.AuctionID
+	}
+
+	return messageEventID(msg)
+}
@@ -2,13 +2,17 @@ package events
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// streamMaxLen caps each event stream. Trimming is approximate, and only
+// ever drops entries far older than anything still pending.
+const streamMaxLen = 10000
+
+// EventPublisher appends events to a Redis stream per event type, so they
+// wait for consumers that are down instead of being dropped.
 type EventPublisher struct {
 	client *redis.Client
 }
@@ -19,33 +23,25 @@ func NewEventPublisher(client *redis.Client) *EventPublisher {
 	}
 }
 
-func (p *EventPublisher) PublishAuctionEnded(ctx context.Context, event AuctionEndedEvent) error {
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal auction ended event: %w", err)
-	}
-
-	err = p.client.Publish(ctx, EventAuctionEnded, data).Err()
-	if err != nil {
-		return fmt.Errorf("failed to publish auction ended event: %w", err)
+// Publish appends an already encoded event to channel. Events that must not
+// be lost go through the outbox, which relays them here under the ID of
+// their outbox message, so an event relayed twice is still recognised as
+// one. Replayed dead letters keep their original ID for the same reason.
+func (p *EventPublisher) Publish(ctx context.Context, eventID, channel string, data []byte) error {
+	if err := p.add(ctx, eventID, channel, data); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", channel, err)
 	}
-
-	log.Printf("Published auction ended event for auction %s", event.AuctionID)
 	return nil
 }
 
-func (p *EventPublisher) PublishPlayerOutbid(ctx context.Context, event UserOutbidEvent) error {
-	data, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal user outbid event: %w", err)
-	}
-
-	err = p.client.Publish(ctx, EventUserOutbid, data).Err()
-	if err != nil {
-		return fmt.Errorf("failed to publish user outbid event: %w", err)
-	}
-
-	log.Printf("Published outbid event for user %s on auction %s", event.OutbidUserID, event.AuctionID)
-	return nil
-
+func (p *EventPublisher) add(ctx context.Context, eventID, stream string, data []byte) error {
+	return p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			messageIDField:   eventID,
+			messageDataField: data,
+		},
+	}).Err()
 }
@@ -0,0 +1,29 @@
+package events
+
+import "time"
+
+// RetryPolicy controls how a channel's handler is retried when it fails.
+// The delay between attempts doubles from BaseBackoff up to MaxBackoff, and
+// once MaxAttempts attempts have failed the event is dead-lettered.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultRetryPolicy applies to channels without a policy of their own.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseBackoff: time.Second,
+	MaxBackoff:  time.Minute,
+}
+
+// Backoff is the delay before the attempt following the given number of
+// failed attempts.
+func (p RetryPolicy) Backoff(failures int) time.Duration {
+	backoff := p.BaseBackoff
+	for i := 1; i < failures && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.MaxBackoff)
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SecondChanceOfferHandler asks the next highest bidder of an auction whose
+// winner never paid to pay for it instead.
+type SecondChanceOfferHandler struct {
+	notificationService NotificationService
+}
+
+func NewSecondChanceOfferEventHandler(notificationService NotificationService) *SecondChanceOfferHandler {
+	return &SecondChanceOfferHandler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *SecondChanceOfferHandler) Handle(ctx context.Context, data []byte) error {
+	var event SecondChanceOfferEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal second chance offer event: %w", err)
+	}
+
+	if err := h.notificationService.NotifySecondChanceOffer(ctx, event.UserID, event.AuctionID, event.Amount); err != nil {
+		return fmt.Errorf("failed to send second chance offer: %w", err)
+	}
+
+	return nil
+}
@@ -4,57 +4,383 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/aglili/auction-app/internal/domain"
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	messageIDField   = "id"
+	messageDataField = "data"
+
+	readCount = 10
+	readBlock = 5 * time.Second
+	// a delivery left unacknowledged this long is assumed to belong to a
+	// consumer that crashed, and is claimed by another
+	reclaimIdle     = time.Minute
+	reclaimInterval = 30 * time.Second
+	// deliveries after which an event is dead-lettered without another
+	// attempt, so an event that keeps crashing its consumer is given up on
+	maxDeliveries = 5
+)
+
+// ChannelConfig sets how the events of one channel are handled.
+type ChannelConfig struct {
+	// Workers caps how many of the channel's events are handled at once.
+	// Events for the same auction are always handled one at a time.
+	Workers int
+	Retry   RetryPolicy
+}
+
+// EventSubscriber consumes event streams as a member of a consumer group.
+// A failing handler is retried according to its channel's RetryPolicy and
+// the event is dead-lettered once the policy gives up. Events are only
+// acknowledged after that, so an event whose consumer dies is delivered
+// again.
 type EventSubscriber struct {
-	client *redis.Client
-	pubsub *redis.PubSub
+	client      *redis.Client
+	group       string
+	consumer    string
+	streams     []string
+	ledger      *eventLedger
+	deadLetters domain.DeadLetterRepository
+	channels    map[string]ChannelConfig
 }
 
-func NewEventSubscriber(client *redis.Client) *EventSubscriber {
+func NewEventSubscriber(client *redis.Client, group, consumer string, deadLetters domain.DeadLetterRepository, channels map[string]ChannelConfig) *EventSubscriber {
 	return &EventSubscriber{
-		client: client,
+		client:      client,
+		group:       group,
+		consumer:    consumer,
+		ledger:      newEventLedger(client, group),
+		deadLetters: deadLetters,
+		channels:    channels,
 	}
 }
 
-func (s *EventSubscriber) Subscribe(ctx context.Context, channels ...string) error {
-	s.pubsub = s.client.Subscribe(ctx, channels...)
+func (s *EventSubscriber) channelConfig(channel string) ChannelConfig {
+	config, ok := s.channels[channel]
+	if !ok {
+		return ChannelConfig{Workers: 1, Retry: DefaultRetryPolicy}
+	}
+	return config
+}
 
-	_, err := s.pubsub.Receive(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to subscribe: %w", err)
+// Subscribe joins the consumer group on each stream, creating the stream and
+// the group if they don't exist yet.
+func (s *EventSubscriber) Subscribe(ctx context.Context, channels ...string) error {
+	for _, channel := range channels {
+		err := s.client.XGroupCreateMkStream(ctx, channel, s.group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+		}
 	}
 
+	s.streams = channels
+
 	log.Printf("Subscribed to channels : %v", channels)
 	return nil
 }
 
+// Listen handles events until ctx is cancelled. Events already read when
+// that happens are handled before it returns; later retries are left to
+// other consumers.
 func (s *EventSubscriber) Listen(ctx context.Context, handlers map[string]EventHandler) error {
-	if s.pubsub == nil {
+	if len(s.streams) == 0 {
 		return fmt.Errorf("not subscribed to any channels")
 	}
 
-	ch := s.pubsub.Channel()
+	pools := make(map[string]*shardedPool, len(s.streams))
+	for _, stream := range s.streams {
+		handler, exists := handlers[stream]
+		if !exists {
+			log.Printf("No handler for channel: %s", stream)
+			continue
+		}
+
+		var pool *shardedPool
+		pool = newShardedPool(s.channelConfig(stream).Workers, func(d delivery) {
+			s.handle(ctx, pool, handler, stream, d)
+		})
+		pools[stream] = pool
+	}
+
+	reclaimed := make(chan struct{})
+	go func() {
+		defer close(reclaimed)
+		s.reclaim(ctx, pools)
+	}()
+
+	// read only events never delivered to the group; anything pending is
+	// picked up by reclaim
+	args := make([]string, 0, 2*len(s.streams))
+	args = append(args, s.streams...)
+	for range s.streams {
+		args = append(args, ">")
+	}
+
+	for ctx.Err() == nil {
+		streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.group,
+			Consumer: s.consumer,
+			Streams:  args,
+			Count:    readCount,
+			Block:    readBlock,
+		}).Result()
+
+		if ctx.Err() != nil {
+			break
+		}
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			log.Printf("Error reading events: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, stream := range streams {
+			pool, exists := pools[stream.Stream]
+			if !exists {
+				continue
+			}
+			for _, msg := range stream.Messages {
+				pool.dispatch(ctx, delivery{msg: msg, attempt: 1})
+			}
+		}
+	}
+
+	log.Println("Stopping event listener")
+
+	<-reclaimed
+	for _, pool := range pools {
+		pool.close()
+	}
+
+	return nil
+}
+
+// handle makes one attempt at handling an event. A failed attempt is
+// retried under the channel's policy by dispatching it to the pool again
+// after its backoff, and the event is acknowledged once it succeeds or is
+// dead-lettered. Events the ledger has seen handled already are
+// acknowledged without running the handler.
+//
+// An attempt already started when ctx is cancelled runs to completion, but
+// no further attempts are made.
+func (s *EventSubscriber) handle(ctx context.Context, pool *shardedPool, handler EventHandler, stream string, d delivery) {
+	msg := d.msg
+	eventID := messageEventID(msg)
+	workCtx := context.WithoutCancel(ctx)
+
+	if d.attempt > 1 && ctx.Err() != nil {
+		// stopping; the event stays pending for another consumer
+		s.ledger.release(workCtx, stream, eventID)
+		return
+	}
+
+	// retries still hold the claim taken by the first attempt
+	if d.attempt == 1 {
+		state, err := s.ledger.begin(workCtx, stream, eventID)
+		if err != nil {
+			log.Printf("Error checking event %s on channel %s: %v", eventID, stream, err)
+			return
+		}
+
+		switch state {
+		case eventInProgress:
+			// another consumer holds it; it is retried if that consumer dies
+			return
+		case eventProcessed:
+			log.Printf("Skipping duplicate event %s on channel %s", eventID, stream)
+			s.ack(workCtx, stream, msg.ID)
+			return
+		}
+	}
+
+	policy := s.channelConfig(stream).Retry
+
+	data, _ := msg.Values[messageDataField].(string)
+
+	err := handler.Handle(workCtx, []byte(data))
+	if err == nil {
+		s.ledger.finish(workCtx, stream, eventID)
+		s.ack(workCtx, stream, msg.ID)
+		return
+	}
+
+	log.Printf("Error handling event %s on channel %s (attempt %d/%d): %v", eventID, stream, d.attempt, policy.MaxAttempts, err)
+
+	if d.attempt >= policy.MaxAttempts {
+		// given up so a replay of the dead letter, or a redelivery if it
+		// can't be stored, is handled rather than skipped
+		s.ledger.release(workCtx, stream, eventID)
+		s.deadLetter(workCtx, stream, msg, d.attempt, err)
+		return
+	}
+
+	retry := delivery{msg: msg, attempt: d.attempt + 1}
+	pool.later(ctx, retry, func() bool {
+		return s.wait(ctx, stream, msg.ID, eventID, policy.Backoff(d.attempt))
+	}, func() {
+		// stopping; the event stays pending for another consumer
+		s.ledger.release(workCtx, stream, eventID)
+	})
+}
+
+// wait sleeps for backoff before a retry, keeping hold of the event in the
+// meantime so reclaim doesn't hand it to another consumer. It reports false
+// if ctx ends first.
+func (s *EventSubscriber) wait(ctx context.Context, stream, entryID, eventID string, backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	refresh := time.NewTicker(reclaimIdle / 2)
+	defer refresh.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Stopping event listener")
-			return s.pubsub.Close()
-		case msg := <-ch:
-			handler, exists := handlers[msg.Channel]
-			if !exists {
-				log.Printf("No handler for channel: %s", msg.Channel)
+			return false
+		case <-timer.C:
+			return true
+		case <-refresh.C:
+			// claiming an entry we already own resets its idle time
+			err := s.client.XClaimJustID(ctx, &redis.XClaimArgs{
+				Stream:   stream,
+				Group:    s.group,
+				Consumer: s.consumer,
+				Messages: []string{entryID},
+			}).Err()
+			if err != nil {
+				log.Printf("Error holding event %s on channel %s: %v", eventID, stream, err)
+			}
+			s.ledger.extend(ctx, stream, eventID)
+		}
+	}
+}
+
+func (s *EventSubscriber) ack(ctx context.Context, stream, id string) {
+	if err := s.client.XAck(ctx, stream, s.group, id).Err(); err != nil {
+		log.Printf("Error acknowledging event %s on channel %s: %v", id, stream, err)
+	}
+}
+
+// messageEventID returns the ID an event was published under. Entries
+// without one fall back to their stream entry ID, which still catches
+// redeliveries of the same entry.
+func messageEventID(msg redis.XMessage) string {
+	if id, ok := msg.Values[messageIDField].(string); ok && id != "" {
+		return id
+	}
+	return msg.ID
+}
+
+func (s *EventSubscriber) reclaim(ctx context.Context, pools map[string]*shardedPool) {
+	ticker := time.NewTicker(reclaimInterval)
+	defer ticker.Stop()
+
+	for {
+		for stream, pool := range pools {
+			s.reclaimStream(ctx, pool, stream)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reclaimStream takes over events that have sat unacknowledged for too long
+// and retries them, dead-lettering those that keep failing.
+func (s *EventSubscriber) reclaimStream(ctx context.Context, pool *shardedPool, stream string) {
+	start := "0-0"
+
+	for {
+		msgs, next, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    s.group,
+			Consumer: s.consumer,
+			MinIdle:  reclaimIdle,
+			Start:    start,
+			Count:    readCount,
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error reclaiming events on channel %s: %v", stream, err)
+			}
+			return
+		}
+
+		for _, msg := range msgs {
+			deliveries, err := s.deliveries(ctx, stream, msg.ID)
+			if err != nil {
+				log.Printf("Error reading deliveries of event %s on channel %s: %v", msg.ID, stream, err)
 				continue
 			}
 
-			go func(m *redis.Message) {
-				if err := handler.Handle(ctx, []byte(m.Payload)); err != nil {
-					log.Printf("Error handling event on channel %s: %v", m.Channel, err)
-				}
-			}(msg)
+			if deliveries > maxDeliveries {
+				s.deadLetter(ctx, stream, msg, int(deliveries), fmt.Errorf("delivered %d times without being acknowledged", deliveries))
+				continue
+			}
+
+			if !pool.dispatch(ctx, delivery{msg: msg, attempt: 1}) {
+				return
+			}
+		}
+
+		if next == "0-0" {
+			return
 		}
+		start = next
 	}
 }
+
+func (s *EventSubscriber) deliveries(ctx context.Context, stream, id string) (int64, error) {
+	pending, err := s.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  s.group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	return pending[0].RetryCount, nil
+}
+
+// deadLetter stores an event that couldn't be handled so it can be
+// inspected and replayed, and acknowledges it so it isn't retried again. If
+// it can't be stored it stays pending and is retried instead. It leaves the
+// ledger alone, since a reclaimed event may be claimed by another consumer.
+func (s *EventSubscriber) deadLetter(ctx context.Context, stream string, msg redis.XMessage, attempts int, cause error) {
+	eventID := messageEventID(msg)
+	data, _ := msg.Values[messageDataField].(string)
+
+	deadLetter := &domain.DeadLetter{
+		EventID:  eventID,
+		Channel:  stream,
+		Payload:  data,
+		Error:    cause.Error(),
+		Attempts: attempts,
+	}
+
+	if err := s.deadLetters.CreateDeadLetter(ctx, deadLetter); err != nil {
+		log.Printf("Error dead-lettering event %s on channel %s: %v", eventID, stream, err)
+		return
+	}
+
+	s.ack(ctx, stream, msg.ID)
+
+	log.Printf("Dead-lettered event %s on channel %s after %d attempts: %v", eventID, stream, attempts, cause)
+}
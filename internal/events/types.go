@@ -4,28 +4,55 @@ import (
 	"context"
 	"time"
 
+	"github.com/aglili/auction-app/internal/domain"
 	"github.com/google/uuid"
 )
 
 const (
-	EventAuctionEnded = "auction:ended"
-	EventUserOutbid   = "user:outbid"
+	EventAuctionEnded      = "auction:ended"
+	EventUserOutbid        = "user:outbid"
+	EventAuctionExtended   = "auction:extended"
+	EventPaymentCompleted  = "payment:completed"
+	EventSecondChanceOffer = "auction:second_chance"
 )
 
 type AuctionEndedEvent struct {
-	AuctionID  uuid.UUID `json:"auction_id"`
-	WinnerID   uuid.UUID `json:"winner_id"`
-	FinalPrice float64   `json:"final_price"`
-	EndedAt    time.Time `json:"ended_at"`
+	AuctionID  uuid.UUID     `json:"auction_id"`
+	WinnerID   uuid.UUID     `json:"winner_id"`
+	FinalPrice domain.Amount `json:"final_price"`
+	EndedAt    time.Time     `json:"ended_at"`
 }
 
 type UserOutbidEvent struct {
-	AuctionID    uuid.UUID `json:"auction_id"`
-	OutbidUserID uuid.UUID `json:"outbid_user_id"`
-	OldBid       float64   `json:"old_bid"`
-	NewBid       float64   `json:"new_bid"`
-	NewBidderID  uuid.UUID `json:"new_bidder_id"`
-	OutbidAt     time.Time `json:"outbid_at"`
+	AuctionID    uuid.UUID     `json:"auction_id"`
+	OutbidUserID uuid.UUID     `json:"outbid_user_id"`
+	OldBid       domain.Amount `json:"old_bid"`
+	NewBid       domain.Amount `json:"new_bid"`
+	NewBidderID  uuid.UUID     `json:"new_bidder_id"`
+	OutbidAt     time.Time     `json:"outbid_at"`
+}
+
+type AuctionExtendedEvent struct {
+	AuctionID  uuid.UUID `json:"auction_id"`
+	EndTime    time.Time `json:"end_time"`
+	ExtendedAt time.Time `json:"extended_at"`
+}
+
+type PaymentCompletedEvent struct {
+	AuctionID uuid.UUID     `json:"auction_id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Reference string        `json:"reference"`
+	Amount    domain.Amount `json:"amount"`
+	PaidAt    time.Time     `json:"paid_at"`
+}
+
+// SecondChanceOfferEvent offers an auction whose winner never paid to the
+// next highest bidder, at their highest bid.
+type SecondChanceOfferEvent struct {
+	AuctionID uuid.UUID     `json:"auction_id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Amount    domain.Amount `json:"amount"`
+	OfferedAt time.Time     `json:"offered_at"`
 }
 
 type EventHandler interface {
@@ -33,6 +60,9 @@ type EventHandler interface {
 }
 
 type NotificationService interface {
-	NotifyAuctionWon(ctx context.Context, userID, auctionID uuid.UUID, price float64) error
-	NotifyOutbid(ctx context.Context, userID, auctionID uuid.UUID, newBid float64) error
+	NotifyAuctionWon(ctx context.Context, userID, auctionID uuid.UUID, price domain.Amount) error
+	NotifyOutbid(ctx context.Context, userID, auctionID uuid.UUID, newBid domain.Amount) error
+	NotifyAuctionExtended(ctx context.Context, auctionID uuid.UUID, endTime time.Time) error
+	NotifyPaymentCompleted(ctx context.Context, userID, auctionID uuid.UUID, amount domain.Amount) error
+	NotifySecondChanceOffer(ctx context.Context, userID, auctionID uuid.UUID, amount domain.Amount) error
 }
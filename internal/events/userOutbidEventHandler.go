@@ -23,7 +23,7 @@ func (h *UserOutbidHandler) Handle(ctx context.Context, data []byte) error {
 		return fmt.Errorf("failed to unmarshal user outbid event: %w", err)
 	}
 
-	log.Printf("User %s outbid on auction %s: %.2f -> %.2f",
+	log.Printf("User %s outbid on auction %s: %s -> %s",
 		event.OutbidUserID, event.AuctionID, event.OldBid, event.NewBid)
 
 	// Send notification to outbid user
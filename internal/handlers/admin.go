@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AdminHandler struct {
+	deadLetterService domain.DeadLetterService
+}
+
+func NewAdminHandler(deadLetterService domain.DeadLetterService) *AdminHandler {
+	return &AdminHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+func (h *AdminHandler) GetDeadLetters(ctx *gin.Context) {
+	page := utils.GetQueryInt(ctx, "page", 1)
+	limit := utils.GetQueryInt(ctx, "limit", 20)
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = 20
+	}
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	deadLetters, total, err := h.deadLetterService.GetDeadLetters(ctx.Request.Context(), page, limit)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to fetch dead letters")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.PaginatedResponse("successfully fetched dead letters", deadLetters, page, limit, total))
+}
+
+func (h *AdminHandler) GetDeadLetter(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.RespondWithError(ctx, err, "invalid dead letter ID format")
+		return
+	}
+
+	deadLetter, err := h.deadLetterService.GetDeadLetter(ctx.Request.Context(), id)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to fetch dead letter")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("successfully fetched dead letter", deadLetter))
+}
+
+func (h *AdminHandler) ReplayDeadLetter(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.RespondWithError(ctx, err, "invalid dead letter ID format")
+		return
+	}
+
+	deadLetter, err := h.deadLetterService.ReplayDeadLetter(ctx.Request.Context(), id)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to replay dead letter")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("successfully replayed dead letter", deadLetter))
+}
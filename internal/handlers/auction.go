@@ -25,12 +25,18 @@ func NewAuctionHandler(service domain.AuctionService, validator *validator.Valid
 }
 
 type CreateAuctionRequest struct {
-	Title         string   `json:"title" binding:"required"`
-	Description   *string  `json:"description,omitempty"`
-	StartingPrice float64  `json:"starting_price" binding:"required,gt=0"`
-	StartTime     string   `json:"start_time" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
-	EndTime       string   `json:"end_time" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
-	Images        []string `json:"images" binding:"required"`
+	Title              string         `json:"title" binding:"required"`
+	Description        *string        `json:"description,omitempty"`
+	Currency           string         `json:"currency,omitempty"` // defaults to domain.DefaultCurrency
+	StartingPrice      domain.Amount  `json:"starting_price" binding:"required,gt=0"`
+	BidIncrement       *domain.Amount `json:"bid_increment,omitempty" binding:"omitempty,gt=0"`
+	ReservePrice       *domain.Amount `json:"reserve_price,omitempty" binding:"omitempty,gt=0"`
+	BuyNowPrice        *domain.Amount `json:"buy_now_price,omitempty" binding:"omitempty,gt=0"`
+	SoftCloseWindow    int            `json:"soft_close_window_minutes" binding:"omitempty,gte=0,lte=60"`
+	SoftCloseExtension int            `json:"soft_close_extension_minutes" binding:"omitempty,gte=0,lte=60"`
+	StartTime          string         `json:"start_time" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	EndTime            string         `json:"end_time" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	Images             []string       `json:"images" binding:"required"`
 }
 
 func (h *AuctionHandler) CreateAuctionHandler(ctx *gin.Context) {
@@ -66,15 +72,45 @@ func (h *AuctionHandler) CreateAuctionHandler(ctx *gin.Context) {
 		utils.RespondWithError(ctx, nil, "start_time cannot be in the past")
 		return
 	}
+	if req.ReservePrice != nil && *req.ReservePrice < req.StartingPrice {
+		utils.RespondWithError(ctx, nil, "reserve_price cannot be below starting_price")
+		return
+	}
+	if req.BuyNowPrice != nil && *req.BuyNowPrice <= req.StartingPrice {
+		utils.RespondWithError(ctx, nil, "buy_now_price must be above starting_price")
+		return
+	}
+	if req.BuyNowPrice != nil && req.ReservePrice != nil && *req.BuyNowPrice < *req.ReservePrice {
+		utils.RespondWithError(ctx, nil, "buy_now_price cannot be below reserve_price")
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = domain.DefaultCurrency
+	}
+	if !domain.IsSupportedCurrency(req.Currency) {
+		utils.RespondWithError(ctx, nil, "unsupported currency")
+		return
+	}
+	if (req.SoftCloseWindow == 0) != (req.SoftCloseExtension == 0) {
+		utils.RespondWithError(ctx, nil, "soft_close_window_minutes and soft_close_extension_minutes must be set together")
+		return
+	}
 
 	auction := &domain.Auction{
 		Title:         req.Title,
 		Description:   req.Description,
+		Currency:      req.Currency,
 		StartingPrice: req.StartingPrice,
 		CurrentPrice:  req.StartingPrice, // initial = starting price
-		Status:        "open",
-		StartTime:     startTime,
-		EndTime:       endTime,
+		BidIncrement:  req.BidIncrement,
+		ReservePrice:  req.ReservePrice,
+		BuyNowPrice:   req.BuyNowPrice,
+
+		SoftCloseWindow:    req.SoftCloseWindow,
+		SoftCloseExtension: req.SoftCloseExtension,
+		Status:             "open",
+		StartTime:          startTime,
+		EndTime:            endTime,
 	}
 
 	createdAuction, err := h.service.CreateAuction(ctx.Request.Context(), auction, uid, req.Images)
@@ -109,12 +145,20 @@ func (h *AuctionHandler) GetAuction(ctx *gin.Context) {
 		ID:            auction.ID,
 		Title:         auction.Title,
 		Description:   auction.Description,
+		Currency:      auction.Currency,
 		StartingPrice: auction.StartingPrice,
 		CurrentPrice:  auction.CurrentPrice,
-		Status:        auction.Status,
-		StartTime:     auction.StartTime,
-		EndTime:       auction.EndTime,
-		Images:        auction.Images,
+		BidIncrement:  auction.BidIncrement,
+		MinimumBid:    auction.MinimumBid(),
+		ReserveMet:    auction.ReserveMet(auction.CurrentPrice),
+		BuyNowPrice:   auction.BuyNowPrice,
+
+		SoftCloseWindow:    auction.SoftCloseWindow,
+		SoftCloseExtension: auction.SoftCloseExtension,
+		Status:             auction.Status,
+		StartTime:          auction.StartTime,
+		EndTime:            auction.EndTime,
+		Images:             auction.Images,
 	}
 
 	ctx.JSON(http.StatusOK, utils.SuccessResponse("successfully fetched auction", auctionResponse))
@@ -159,6 +203,8 @@ func (h *AuctionHandler) GetUserAuctions(ctx *gin.Context) {
 			Status:        auction.Status,
 			StartingPrice: auction.StartingPrice,
 			CurrentPrice:  auction.CurrentPrice,
+			BidIncrement:  auction.BidIncrement,
+			BuyNowPrice:   auction.BuyNowPrice,
 			StartTime:     auction.StartTime,
 			EndTime:       auction.EndTime,
 			CreatedAt:     auction.CreatedAt,
@@ -209,6 +255,8 @@ func (h *AuctionHandler) GetOpenAuctions(ctx *gin.Context) {
 			Status:        auction.Status,
 			StartingPrice: auction.StartingPrice,
 			CurrentPrice:  auction.CurrentPrice,
+			BidIncrement:  auction.BidIncrement,
+			BuyNowPrice:   auction.BuyNowPrice,
 			StartTime:     auction.StartTime,
 			EndTime:       auction.EndTime,
 			CreatedAt:     auction.CreatedAt,
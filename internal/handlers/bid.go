@@ -24,7 +24,8 @@ func NewBidHandler(service domain.BidService, validator *validator.Validate) *Bi
 }
 
 type CreateBidRequest struct {
-	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Amount    domain.Amount `json:"amount" binding:"required,gt=0"`
+	MaxAmount domain.Amount `json:"max_amount,omitempty" binding:"omitempty,gtfield=Amount"`
 }
 
 func (h *BidHandler) CreateBid(ctx *gin.Context) {
@@ -67,11 +68,127 @@ func (h *BidHandler) CreateBid(ctx *gin.Context) {
 		return
 	}
 
-	err = h.bidService.CreateBid(ctx.Request.Context(), auctionID, uid, req.Amount)
+	result, err := h.bidService.CreateBid(ctx.Request.Context(), auctionID, uid, req.Amount, req.MaxAmount)
 	if err != nil {
 		utils.RespondWithError(ctx, err, "failed to create bid")
 		return
 	}
 
-	ctx.JSON(http.StatusOK, utils.SuccessResponse("bid created successfully", nil))
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("bid created successfully", result))
+}
+
+func (h *BidHandler) BuyNow(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		response := utils.ErrorResponse("Unauthorized", errors.New("user not authenticated"))
+		if response.Error != nil {
+			response.Error.Code = utils.ErrCodeUnauthorized
+		}
+		ctx.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		response := utils.ErrorResponse("Invalid user ID", err)
+		if response.Error != nil {
+			response.Error.Code = utils.ErrCodeInvalidInput
+		}
+		ctx.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	auctionIDString := utils.GetParamStr(ctx, "id", "")
+
+	auctionID, err := uuid.Parse(auctionIDString)
+	if err != nil {
+		response := utils.ErrorResponse("Invalid auction ID", err)
+		if response.Error != nil {
+			response.Error.Code = utils.ErrCodeInvalidInput
+		}
+		ctx.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	result, err := h.bidService.BuyNow(ctx.Request.Context(), auctionID, uid)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to buy auction")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("auction bought successfully", result))
+}
+
+func (h *BidHandler) GetAuctionBids(ctx *gin.Context) {
+	auctionIDString := utils.GetParamStr(ctx, "id", "")
+
+	auctionID, err := uuid.Parse(auctionIDString)
+	if err != nil {
+		response := utils.ErrorResponse("Invalid auction ID", err)
+		if response.Error != nil {
+			response.Error.Code = utils.ErrCodeInvalidInput
+		}
+		ctx.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	limit := utils.GetQueryInt(ctx, "limit", 20)
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	bids, nextCursor, err := h.bidService.GetAuctionBids(ctx.Request.Context(), auctionID, ctx.Query("cursor"), limit)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to fetch bids")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.CursorPaginatedResponse("successfully fetched bids", bids, limit, nextCursor))
+}
+
+func (h *BidHandler) GetUserBids(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		response := utils.ErrorResponse("Unauthorized", errors.New("user not authenticated"))
+		if response.Error != nil {
+			response.Error.Code = utils.ErrCodeUnauthorized
+		}
+		ctx.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		response := utils.ErrorResponse("Invalid user ID", err)
+		if response.Error != nil {
+			response.Error.Code = utils.ErrCodeInvalidInput
+		}
+		ctx.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	page := utils.GetQueryInt(ctx, "page", 1)
+	limit := utils.GetQueryInt(ctx, "limit", 10)
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	bids, total, err := h.bidService.GetUserBids(ctx.Request.Context(), uid, page, limit)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to fetch bids")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.PaginatedResponse("successfully fetched bids", bids, page, limit, total))
 }
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aglili/auction-app/internal/payment"
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// FakeCheckoutHandler serves the checkout links of the fake payment
+// provider. It is only routed when that provider is in use.
+type FakeCheckoutHandler struct {
+	provider *payment.Fake
+}
+
+func NewFakeCheckoutHandler(provider *payment.Fake) *FakeCheckoutHandler {
+	return &FakeCheckoutHandler{
+		provider: provider,
+	}
+}
+
+// Checkout pays the charge behind a checkout link, as if the payer had gone
+// through a real provider's checkout page.
+func (h *FakeCheckoutHandler) Checkout(ctx *gin.Context) {
+	reference := ctx.Param("reference")
+
+	err := h.provider.Pay(ctx.Request.Context(), reference)
+	if errors.Is(err, payment.ErrUnknownReference) {
+		utils.RespondWithError(ctx, utils.NewAppError(err, "payment not found", utils.ErrCodeNotFound, http.StatusNotFound), "")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to complete payment")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("payment completed", gin.H{"reference": reference}))
+}
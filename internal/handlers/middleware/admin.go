@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminKey only lets through requests carrying apiKey in the
+// X-Admin-Key header. With no key configured, every request is refused.
+func RequireAdminKey(apiKey string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader("X-Admin-Key")
+
+		if apiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			ctx.JSON(http.StatusUnauthorized, utils.ErrorResponse("unauthorized", nil))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	maxIdempotencyKey = 255
+	// a request still marked in progress after this long is assumed to have
+	// died with its server, and the key can be used again
+	idempotencyLockTTL = time.Minute
+)
+
+// idempotentResponse is what is stored under an idempotency key. Status is
+// zero while the first request is still being handled.
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotent makes a request sent with an Idempotency-Key header act only
+// once. The response is kept for ttl and returned, without running the
+// handler, to any retry with the same key. Keys are scoped to the user, so
+// it must run after RequireUserAuth.
+//
+// A retry while the first request is still running gets a 409, and reusing
+// a key for a different request gets a 422. Server errors are not kept, so
+// the request can be retried.
+func Idempotent(client *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			utils.RespondWithError(ctx, utils.NewAppError(nil, "idempotency key is too long", utils.ErrCodeInvalidInput, http.StatusBadRequest), "")
+			ctx.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			utils.RespondWithError(ctx, utils.NewAppError(err, "failed to read request", utils.ErrCodeInvalidInput, http.StatusBadRequest), "")
+			ctx.Abort()
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := cache.IdempotencyKey(ctx.GetString("user_id"), key)
+		fingerprint := requestFingerprint(ctx.Request, body)
+
+		pending, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint})
+		claimed, err := client.SetNX(ctx.Request.Context(), storeKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			utils.RespondWithError(ctx, err, "failed to check idempotency key")
+			ctx.Abort()
+			return
+		}
+
+		if !claimed {
+			replayResponse(ctx, client, storeKey, fingerprint)
+			ctx.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			if err := client.Del(ctx.Request.Context(), storeKey).Err(); err != nil {
+				log.Printf("Failed to release idempotency key %s: %v", key, err)
+			}
+			return
+		}
+
+		stored, err := json.Marshal(idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		if err == nil {
+			err = client.Set(ctx.Request.Context(), storeKey, stored, ttl).Err()
+		}
+		if err != nil {
+			log.Printf("Failed to store response for idempotency key %s: %v", key, err)
+		}
+	}
+}
+
+// replayResponse answers a request whose idempotency key was already used.
+func replayResponse(ctx *gin.Context, client *redis.Client, storeKey, fingerprint string) {
+	data, err := client.Get(ctx.Request.Context(), storeKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// the first request failed and released the key just now
+		utils.RespondWithError(ctx, utils.NewAppError(nil, "request with this idempotency key is still in progress", utils.ErrCodeConflict, http.StatusConflict), "")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to check idempotency key")
+		return
+	}
+
+	var stored idempotentResponse
+	if err := json.Unmarshal(data, &stored); err != nil {
+		utils.RespondWithError(ctx, err, "failed to check idempotency key")
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		utils.RespondWithError(ctx, utils.NewAppError(nil, "idempotency key was used for a different request", utils.ErrCodeInvalidInput, http.StatusUnprocessableEntity), "")
+		return
+	}
+
+	if stored.Status == 0 {
+		utils.RespondWithError(ctx, utils.NewAppError(nil, "request with this idempotency key is still in progress", utils.ErrCodeConflict, http.StatusConflict), "")
+		return
+	}
+
+	ctx.Header("Idempotent-Replayed", "true")
+	ctx.Data(stored.Status, stored.ContentType, stored.Body)
+}
+
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body as it is written.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to the Redis server in TEST_REDIS_URL, skipping the
+// test when it isn't set.
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opt)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// idempotencyRouter serves POST /bids behind Idempotent for a single user.
+// The handler counts its calls and answers status with the count. If block
+// is set, it waits for it to close before answering.
+type idempotencyRouter struct {
+	router *gin.Engine
+	calls  atomic.Int32
+	status atomic.Int32
+	// entered receives a value each time the handler starts
+	entered chan struct{}
+	block   chan struct{}
+}
+
+func newIdempotencyRouter(t *testing.T, client *redis.Client) *idempotencyRouter {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.NewString()
+	keys := []string{"key-1", "key-2"}
+	t.Cleanup(func() {
+		for _, key := range keys {
+			client.Del(context.Background(), cache.IdempotencyKey(userID, key))
+		}
+	})
+
+	r := &idempotencyRouter{router: gin.New(), entered: make(chan struct{}, 10)}
+	r.status.Store(http.StatusCreated)
+
+	r.router.POST("/bids",
+		func(ctx *gin.Context) {
+			ctx.Set("user_id", userID)
+			ctx.Next()
+		},
+		Idempotent(client, time.Minute),
+		func(ctx *gin.Context) {
+			n := r.calls.Add(1)
+			r.entered <- struct{}{}
+			if r.block != nil {
+				<-r.block
+			}
+			ctx.JSON(int(r.status.Load()), gin.H{"call": n})
+		},
+	)
+
+	return r
+}
+
+func (r *idempotencyRouter) post(key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/bids", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	r.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotentReplaysStoredResponse(t *testing.T) {
+	r := newIdempotencyRouter(t, testRedis(t))
+
+	first := r.post("key-1", `{"amount":10}`)
+	second := r.post("key-1", `{"amount":10}`)
+
+	if r.calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", r.calls.Load())
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay answered %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay isn't marked as replayed")
+	}
+	if ct := second.Header().Get("Content-Type"); ct != first.Header().Get("Content-Type") {
+		t.Fatalf("replay has content type %q, want %q", ct, first.Header().Get("Content-Type"))
+	}
+
+	// another key is another request
+	r.post("key-2", `{"amount":10}`)
+	if r.calls.Load() != 2 {
+		t.Fatalf("handler ran %d times after a new key, want 2", r.calls.Load())
+	}
+}
+
+func TestIdempotentRejectsRequestInFlight(t *testing.T) {
+	r := newIdempotencyRouter(t, testRedis(t))
+	r.block = make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- r.post("key-1", `{"amount":10}`) }()
+	<-r.entered
+
+	if rec := r.post("key-1", `{"amount":10}`); rec.Code != http.StatusConflict {
+		t.Fatalf("retry while in flight answered %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	close(r.block)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Fatalf("first request answered %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if r.calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", r.calls.Load())
+	}
+}
+
+func TestIdempotentRejectsReusedKey(t *testing.T) {
+	r := newIdempotencyRouter(t, testRedis(t))
+
+	r.post("key-1", `{"amount":10}`)
+	rec := r.post("key-1", `{"amount":20}`)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key answered %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if r.calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", r.calls.Load())
+	}
+}
+
+func TestIdempotentDoesNotKeepServerErrors(t *testing.T) {
+	r := newIdempotencyRouter(t, testRedis(t))
+	r.status.Store(http.StatusInternalServerError)
+
+	r.post("key-1", `{"amount":10}`)
+	r.status.Store(http.StatusCreated)
+	rec := r.post("key-1", `{"amount":10}`)
+
+	if rec.Code != http.StatusCreated || r.calls.Load() != 2 {
+		t.Fatalf("retry after a server error answered %d after %d calls, want %d after 2", rec.Code, r.calls.Load(), http.StatusCreated)
+	}
+}
+
+func TestIdempotentWithoutKey(t *testing.T) {
+	r := newIdempotencyRouter(t, testRedis(t))
+
+	for i := 1; i <= 2; i++ {
+		rec := r.post("", `{"amount":10}`)
+		if want := `{"call":` + strconv.Itoa(i) + `}`; rec.Body.String() != want {
+			t.Fatalf("request %d answered %s, want %s", i, rec.Body, want)
+		}
+	}
+}
+
+func TestIdempotentRejectsLongKey(t *testing.T) {
+	r := newIdempotencyRouter(t, testRedis(t))
+
+	rec := r.post(strings.Repeat("k", maxIdempotencyKey+1), `{"amount":10}`)
+	if rec.Code != http.StatusBadRequest || r.calls.Load() != 0 {
+		t.Fatalf("long key answered %d after %d calls, want %d without calling the handler", rec.Code, r.calls.Load(), http.StatusBadRequest)
+	}
+}
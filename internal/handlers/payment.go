@@ -1,93 +1,62 @@
 package handlers
 
 import (
-	"crypto/hmac"
-	"crypto/sha512"
-	"encoding/hex"
-	"encoding/json"
-	"fmt"
 	"io"
-	"log"
 	"net/http"
 
-	"github.com/aglili/auction-app/internal/config"
+	"github.com/aglili/auction-app/internal/domain"
 	"github.com/aglili/auction-app/internal/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-
-
-
-type PaymentHandler struct{
-	cfg *config.Config
+type PaymentHandler struct {
+	paymentService domain.PaymentService
 }
 
-
-
-
-func NewPaymentHandler(cfg *config.Config) *PaymentHandler{
-	return  &PaymentHandler{cfg: cfg}
+func NewPaymentHandler(paymentService domain.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
 }
 
-
-
-
-
+// WebhookEndpoint receives events from the payment provider. Anything but a
+// 200 makes the provider send the event again.
 func (h *PaymentHandler) WebhookEndpoint(ctx *gin.Context) {
 	body, err := io.ReadAll(ctx.Request.Body)
 	if err != nil {
-		utils.RespondWithError(ctx,err,"failed to read request body")
+		utils.RespondWithError(ctx, err, "failed to read request body")
 		return
 	}
 
-	signature := ctx.GetHeader("x-paystack-signature")
-	if signature == ""{
-		utils.RespondWithError(ctx,err,"missing signature header")
+	if err := h.paymentService.HandleWebhook(ctx.Request.Context(), ctx.Request.Header, body); err != nil {
+		utils.RespondWithError(ctx, err, "failed to handle webhook")
 		return
 	}
 
-	if !verifyPaystackSignature(h.cfg.PaystackSecretKey,body,signature){
-		utils.RespondWithError(ctx,fmt.Errorf("invalid signature"),"invalid signature")
-		return
-	}
+	ctx.Status(http.StatusOK)
+}
 
-	var event map[string]any
-	if err := json.Unmarshal(body,&event);err != nil {
-		utils.RespondWithError(ctx,err,"failed to parse request json")
+// VerifyPayment checks a payment with the provider and returns its status,
+// and that of its auction, to the user asked to make it.
+func (h *PaymentHandler) VerifyPayment(ctx *gin.Context) {
+	uid, err := uuid.Parse(ctx.GetString("user_id"))
+	if err != nil {
+		utils.RespondWithError(ctx, err, "invalid session")
 		return
 	}
 
-
-	eventType := event["event"].(string)
-	log.Printf("event of type:[%v] has been received",eventType)
-
-	switch eventType{
-	case "charge.success":
-		data := event["data"].(map[string]any)
-		log.Printf("data received: %v",data)
-		// TODO: handle payments later with an event
-	default:
-		fmt.Println("Unhandled event:", eventType)
+	reference := ctx.Param("reference")
+	if reference == "" {
+		utils.RespondWithError(ctx, nil, "reference is required")
+		return
 	}
 
-
-
-	ctx.Status(http.StatusOK)
+	status, err := h.paymentService.VerifyPayment(ctx.Request.Context(), reference, uid)
+	if err != nil {
+		utils.RespondWithError(ctx, err, "failed to verify payment")
+		return
 	}
 
-
-
-
-
-
-
-
-
-func verifyPaystackSignature(secretKey string, body []byte,signature string) bool  {
-	mac := hmac.New(sha512.New,[]byte(secretKey))
-	mac.Write(body)
-	expectedMac := mac.Sum(nil)
-	expectedSignature := hex.EncodeToString(expectedMac)
-	return  hmac.Equal([]byte(signature),[]byte(expectedSignature))
-	
-}
\ No newline at end of file
+	ctx.JSON(http.StatusOK, utils.SuccessResponse("successfully verified payment", status))
+}
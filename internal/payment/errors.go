@@ -0,0 +1,8 @@
+package payment
+
+import "errors"
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownReference = errors.New("unknown payment reference")
+)
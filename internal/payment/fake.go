@@ -0,0 +1,228 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+)
+
+const (
+	// FakeCheckoutPath is where the fake provider sends payers; the reference
+	// of the payment follows it
+	FakeCheckoutPath = "/api/v1/payments/fake/checkout/"
+	fakeWebhookPath  = "/api/v1/payments/webhook"
+
+	fakeRefunded = "refunded"
+)
+
+// Fake takes payments in memory so the app can run without a real payment
+// provider. Its checkout links point back at the app, and opening one pays
+// the charge and delivers a signed webhook for it, as a real provider would.
+// Charges are lost on restart and aren't shared between replicas.
+type Fake struct {
+	baseURL string
+	secret  string
+	client  *http.Client
+
+	mu      sync.Mutex
+	charges map[string]*fakeCharge
+}
+
+type fakeCharge struct {
+	email  string
+	amount domain.Money
+	status string
+	paidAt *time.Time
+}
+
+type fakeEvent struct {
+	Event string          `json:"event"`
+	Data  fakeTransaction `json:"data"`
+}
+
+type fakeTransaction struct {
+	Reference string     `json:"reference"`
+	Amount    int64      `json:"amount"`
+	Currency  string     `json:"currency"`
+	Status    string     `json:"status"`
+	PaidAt    *time.Time `json:"paid_at,omitempty"`
+}
+
+// NewFake creates a fake provider for the app served at baseURL. Webhooks
+// are signed with secret.
+func NewFake(baseURL, secret string) *Fake {
+	return &Fake{
+		baseURL: baseURL,
+		secret:  secret,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		charges: make(map[string]*fakeCharge),
+	}
+}
+
+// transaction describes the charge the way a provider's API would, with the
+// amount in minor units.
+func (c *fakeCharge) transaction(reference string) fakeTransaction {
+	return fakeTransaction{
+		Reference: reference,
+		Amount:    int64(c.amount.Amount),
+		Currency:  c.amount.Currency,
+		Status:    c.status,
+		PaidAt:    c.paidAt,
+	}
+}
+
+func (f *Fake) Initialize(ctx context.Context, email string, amount domain.Money, reference string) (*domain.PaymentInitialization, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.charges[reference]; exists {
+		return nil, fmt.Errorf("payment initialization failed: duplicate reference %s", reference)
+	}
+
+	f.charges[reference] = &fakeCharge{
+		email:  email,
+		amount: amount,
+		status: domain.PaymentStatusPending,
+	}
+
+	return &domain.PaymentInitialization{
+		Reference:        reference,
+		AuthorizationURL: f.baseURL + FakeCheckoutPath + url.PathEscape(reference),
+	}, nil
+}
+
+func (f *Fake) Verify(ctx context.Context, reference string) (*domain.PaymentVerification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, exists := f.charges[reference]
+	if !exists {
+		return nil, ErrUnknownReference
+	}
+
+	status := charge.status
+	if status == fakeRefunded {
+		status = domain.PaymentStatusFailed
+	}
+
+	payload, err := json.Marshal(charge.transaction(reference))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transaction: %w", err)
+	}
+
+	return &domain.PaymentVerification{
+		Reference: reference,
+		Status:    status,
+		Amount:    charge.amount,
+		PaidAt:    charge.paidAt,
+		Payload:   payload,
+	}, nil
+}
+
+func (f *Fake) Refund(ctx context.Context, reference string, amount domain.Money) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	charge, exists := f.charges[reference]
+	if !exists {
+		return ErrUnknownReference
+	}
+	if charge.status != domain.PaymentStatusSuccess {
+		return fmt.Errorf("refund failed: payment %s is %s", reference, charge.status)
+	}
+
+	charge.status = fakeRefunded
+	return nil
+}
+
+// VerifyWebhook checks the x-fake-signature header, an HMAC of the body
+// keyed with the secret.
+func (f *Fake) VerifyWebhook(header http.Header, body []byte) (*domain.PaymentWebhookEvent, error) {
+	signature := header.Get("x-fake-signature")
+	if signature == "" || !validSignature(f.secret, body, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event fakeEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook data: %w", err)
+	}
+
+	return &domain.PaymentWebhookEvent{
+		Type:      event.Event,
+		Reference: event.Data.Reference,
+		Amount:    domain.Money{Amount: domain.Amount(event.Data.Amount), Currency: event.Data.Currency},
+		Payload:   payload,
+	}, nil
+}
+
+// Pay completes a pending charge and tells the app about it through its
+// webhook. Paying a charge again just delivers the webhook again.
+func (f *Fake) Pay(ctx context.Context, reference string) error {
+	f.mu.Lock()
+	charge, exists := f.charges[reference]
+	if !exists {
+		f.mu.Unlock()
+		return ErrUnknownReference
+	}
+	if charge.status == domain.PaymentStatusPending {
+		now := time.Now()
+		charge.status = domain.PaymentStatusSuccess
+		charge.paidAt = &now
+	}
+	event := fakeEvent{
+		Event: domain.PaymentWebhookChargeSuccess,
+		Data:  charge.transaction(reference),
+	}
+	f.mu.Unlock()
+
+	if event.Data.Status != domain.PaymentStatusSuccess {
+		return fmt.Errorf("payment %s is %s", reference, event.Data.Status)
+	}
+
+	return f.deliver(ctx, event)
+}
+
+func (f *Fake) deliver(ctx context.Context, event fakeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	mac := hmac.New(sha512.New, []byte(f.secret))
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+fakeWebhookPath, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-fake-signature", hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook rejected: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+)
+
+const paystackBaseURL = "https://api.paystack.co"
+
+// Paystack takes payments through the Paystack API.
+type Paystack struct {
+	secretKey string
+	client    *http.Client
+}
+
+func NewPaystack(secretKey string) *Paystack {
+	return &Paystack{
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type paystackInitializeRequest struct {
+	Email       string                 `json:"email"`
+	Amount      int64                  `json:"amount"`
+	Currency    string                 `json:"currency,omitempty"`
+	Reference   string                 `json:"reference,omitempty"`
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Channels    []string               `json:"channels"`
+}
+
+type paystackResponse[T any] struct {
+	Status  bool   `json:"status"`
+	Message string `json:"message"`
+	Data    T      `json:"data"`
+}
+
+type paystackTransaction struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	PaidAt    string `json:"paid_at"`
+}
+
+// money gives the amount of the transaction, which Paystack reports in the
+// minor unit of its currency.
+func (t paystackTransaction) money() domain.Money {
+	return domain.Money{Amount: domain.Amount(t.Amount), Currency: t.Currency}
+}
+
+type paystackEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func (p *Paystack) Initialize(ctx context.Context, email string, amount domain.Money, reference string) (*domain.PaymentInitialization, error) {
+	payload := paystackInitializeRequest{
+		Email:     email,
+		Amount:    int64(amount.Amount),
+		Currency:  amount.Currency,
+		Reference: reference,
+		Channels:  []string{"card", "bank_transfer", "apple_pay", "mobile_money", "qr"},
+	}
+
+	var response paystackResponse[domain.PaymentInitialization]
+	if _, err := p.do(ctx, http.MethodPost, "/transaction/initialize", payload, &response); err != nil {
+		return nil, fmt.Errorf("payment initialization failed: %w", err)
+	}
+
+	if !response.Status {
+		return nil, fmt.Errorf("payment initialization failed: %s", response.Message)
+	}
+
+	return &response.Data, nil
+}
+
+func (p *Paystack) Verify(ctx context.Context, reference string) (*domain.PaymentVerification, error) {
+	var response paystackResponse[json.RawMessage]
+	status, err := p.do(ctx, http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, &response)
+	if status == http.StatusNotFound {
+		return nil, ErrUnknownReference
+	}
+	if err != nil {
+		return nil, fmt.Errorf("payment verification failed: %w", err)
+	}
+
+	if !response.Status {
+		return nil, fmt.Errorf("payment verification failed: %s", response.Message)
+	}
+
+	var transaction paystackTransaction
+	if err := json.Unmarshal(response.Data, &transaction); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	return &domain.PaymentVerification{
+		Reference: transaction.Reference,
+		Status:    paystackStatus(transaction.Status),
+		Amount:    transaction.money(),
+		PaidAt:    parsePaidAt(transaction.PaidAt),
+		Payload:   response.Data,
+	}, nil
+}
+
+func (p *Paystack) Refund(ctx context.Context, reference string, amount domain.Money) error {
+	payload := map[string]any{
+		"transaction": reference,
+		"amount":      int64(amount.Amount),
+	}
+
+	var response paystackResponse[json.RawMessage]
+	if _, err := p.do(ctx, http.MethodPost, "/refund", payload, &response); err != nil {
+		return fmt.Errorf("refund failed: %w", err)
+	}
+
+	if !response.Status {
+		return fmt.Errorf("refund failed: %s", response.Message)
+	}
+
+	return nil
+}
+
+// VerifyWebhook checks the x-paystack-signature header, an HMAC of the body
+// keyed with the secret key.
+func (p *Paystack) VerifyWebhook(header http.Header, body []byte) (*domain.PaymentWebhookEvent, error) {
+	signature := header.Get("x-paystack-signature")
+	if signature == "" || !validSignature(p.secretKey, body, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var event paystackEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+
+	var transaction paystackTransaction
+	if err := json.Unmarshal(event.Data, &transaction); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook data: %w", err)
+	}
+
+	return &domain.PaymentWebhookEvent{
+		Type:      event.Event,
+		Reference: transaction.Reference,
+		Amount:    transaction.money(),
+		Payload:   event.Data,
+	}, nil
+}
+
+// do sends a request to the Paystack API and decodes its response into out.
+// It returns the HTTP status alongside any error.
+func (p *Paystack) do(ctx context.Context, method, path string, payload any, out any) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, paystackBaseURL+path, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+p.secretKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("paystack API error: %s - %s", resp.Status, string(data))
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// paystackStatus maps the status of a Paystack transaction to a payment
+// status. Reversed transactions count as failed. Abandoned ones are still
+// pending, since Paystack marks a transaction abandoned whenever checkout is
+// left and the customer can come back to it.
+func paystackStatus(status string) string {
+	switch status {
+	case "success":
+		return domain.PaymentStatusSuccess
+	case "failed", "reversed":
+		return domain.PaymentStatusFailed
+	default:
+		return domain.PaymentStatusPending
+	}
+}
+
+// parsePaidAt parses the time a transaction was paid, which is empty or
+// null until it is.
+func parsePaidAt(paidAt string) *time.Time {
+	t, err := time.Parse(time.RFC3339, paidAt)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func validSignature(secretKey string, body []byte, signature string) bool {
+	mac := hmac.New(sha512.New, []byte(secretKey))
+	mac.Write(body)
+	expectedSignature := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+}
@@ -3,21 +3,28 @@ package provider
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/aglili/auction-app/internal/config"
+	"github.com/aglili/auction-app/internal/domain"
 	"github.com/aglili/auction-app/internal/events"
 	"github.com/aglili/auction-app/internal/handlers"
+	"github.com/aglili/auction-app/internal/payment"
 	"github.com/aglili/auction-app/internal/repository"
 	"github.com/aglili/auction-app/internal/scheduler"
 	"github.com/aglili/auction-app/internal/service"
 	"github.com/aglili/auction-app/internal/websocket"
+	"github.com/aglili/auction-app/pkg/constants"
 	"github.com/go-playground/validator/v10"
 	"github.com/redis/go-redis/v9"
 )
 
 type Provider struct {
 	DB             *sql.DB
+	Redis          *redis.Client
 	Validator      *validator.Validate
 	UserHandler    *handlers.UserHandler
 	HealthHandler  *handlers.HealthHandler
@@ -25,10 +32,25 @@ type Provider struct {
 	BidHandler     *handlers.BidHandler
 	WsHandler      *handlers.WebSocketHandler
 	PaymentHandler *handlers.PaymentHandler
-	Config         *config.Config
+	AdminHandler   *handlers.AdminHandler
+	// nil unless payments go through the fake provider
+	FakeCheckoutHandler *handlers.FakeCheckoutHandler
+	Config              *config.Config
+
+	scheduler     *scheduler.AuctionScheduler
+	subscriber    *events.EventSubscriber
+	outboxRelay   *events.OutboxRelay
+	consistency   *scheduler.ConsistencyChecker
+	deadlines     *scheduler.PaymentDeadlineChecker
+	connManager   *websocket.ConnectionManager
+	eventHandlers map[string]events.EventHandler
+	workers       sync.WaitGroup
 }
 
-func NewProvider(config *config.Config, db *sql.DB, redis *redis.Client) *Provider {
+// NewProvider wires up the app's dependencies. It refuses to take payments
+// through the fake provider in production, since its checkout takes no
+// money and its webhooks are signed with the app's own secret.
+func NewProvider(config *config.Config, db *sql.DB, redis *redis.Client) (*Provider, error) {
 
 	validator := validator.New()
 
@@ -36,22 +58,72 @@ func NewProvider(config *config.Config, db *sql.DB, redis *redis.Client) *Provid
 	userRepository := repository.NewUserRepository(db)
 	auctionRepository := repository.NewAuctionRepository(db)
 	bidRepository := repository.NewBidRepository(db)
+	outboxRepository := repository.NewOutboxRepository(db)
+	deadLetterRepository := repository.NewDeadLetterRepository(db)
+	paymentRepository := repository.NewPaymentRepository(db)
 
 	wsConnManager := websocket.NewConnectionManager()
 
+	// winners and runners-up must be reached even through a payment provider
+	// outage, while stale outbid and countdown updates are not worth retrying
+	// for long
+	eventChannels := map[string]events.ChannelConfig{
+		events.EventAuctionEnded: {
+			Workers: config.EventWorkers,
+			Retry:   events.RetryPolicy{MaxAttempts: 8, BaseBackoff: 2 * time.Second, MaxBackoff: 2 * time.Minute},
+		},
+		events.EventUserOutbid: {
+			Workers: config.EventWorkers,
+			Retry:   events.RetryPolicy{MaxAttempts: 3, BaseBackoff: 500 * time.Millisecond, MaxBackoff: 5 * time.Second},
+		},
+		events.EventAuctionExtended: {
+			Workers: config.EventWorkers,
+			Retry:   events.RetryPolicy{MaxAttempts: 2, BaseBackoff: 500 * time.Millisecond, MaxBackoff: time.Second},
+		},
+		events.EventPaymentCompleted: {
+			Workers: config.EventWorkers,
+			Retry:   events.DefaultRetryPolicy,
+		},
+		events.EventSecondChanceOffer: {
+			Workers: config.EventWorkers,
+			Retry:   events.RetryPolicy{MaxAttempts: 8, BaseBackoff: 2 * time.Second, MaxBackoff: 2 * time.Minute},
+		},
+	}
+
+	var paymentProvider domain.PaymentProvider
+	var fakeCheckoutHandler *handlers.FakeCheckoutHandler
+	switch config.PaymentProvider {
+	case constants.PAYMENT_PROVIDER_FAKE:
+		if config.AppEnv == constants.PRODUCTION {
+			return nil, fmt.Errorf("the %s payment provider can't be used in %s", constants.PAYMENT_PROVIDER_FAKE, constants.PRODUCTION)
+		}
+		log.Println("Taking payments with the fake payment provider")
+		fake := payment.NewFake(config.AppBaseURL, config.SecretKey)
+		paymentProvider = fake
+		fakeCheckoutHandler = handlers.NewFakeCheckoutHandler(fake)
+	default:
+		paymentProvider = payment.NewPaystack(config.PaystackSecretKey)
+	}
+
 	publisher := events.NewEventPublisher(redis)
-	subscriber := events.NewEventSubscriber(redis)
+	subscriber := events.NewEventSubscriber(redis, config.EventConsumerGroup, config.EventConsumerName, deadLetterRepository, eventChannels)
+	closingQueue := scheduler.NewClosingQueue(redis)
+	outboxRelay := events.NewOutboxRelay(outboxRepository, publisher)
 
 	// services
-	paymentService := service.NewPaymentService(config)
+	paymentService := service.NewPaymentService(config, paymentProvider, paymentRepository, bidRepository, auctionRepository)
 	userService := service.NewUserService(userRepository)
-	auctionService := service.NewAuctionService(auctionRepository)
+	auctionService := service.NewAuctionService(auctionRepository, closingQueue)
 	notificationService := service.NewNotificationService(userRepository, auctionRepository, wsConnManager, paymentService)
-	bidService := service.NewBidService(bidRepository, auctionRepository, redis)
+	deadLetterService := service.NewDeadLetterService(deadLetterRepository, publisher)
+	bidService := service.NewBidService(bidRepository, auctionRepository, redis, closingQueue, config)
 
 	// event handlers
-	auctionEndedEventHandler := events.NewAuctionEventEndedHandler(notificationService, auctionRepository)
+	auctionEndedEventHandler := events.NewAuctionEventEndedHandler(notificationService)
 	outbidEventHandler := events.NewUserOutbidEventHandler(notificationService)
+	auctionExtendedEventHandler := events.NewAuctionExtendedEventHandler(notificationService)
+	paymentCompletedEventHandler := events.NewPaymentCompletedEventHandler(notificationService)
+	secondChanceOfferEventHandler := events.NewSecondChanceOfferEventHandler(notificationService)
 
 	// route handlers
 	userHandler := handlers.NewUserHandler(userService, validator)
@@ -59,36 +131,93 @@ func NewProvider(config *config.Config, db *sql.DB, redis *redis.Client) *Provid
 	bidHandler := handlers.NewBidHandler(bidService, validator)
 	wsHandler := handlers.NewWebSocketHandler(wsConnManager)
 	healthHandler := handlers.NewHealthHandler()
-	paymentHandler := handlers.NewPaymentHandler(config)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	adminHandler := handlers.NewAdminHandler(deadLetterService)
 
 	// scheduler
-	scheduler := scheduler.NewAuctionScheduler(auctionRepository, redis, publisher)
-
-	ctx := context.Background()
-	if err := subscriber.Subscribe(ctx, events.EventAuctionEnded, events.EventUserOutbid); err != nil {
-		log.Fatalf("Failed to subscribe to events: %v", err)
-	}
-
-	go func() {
-		handlers := map[string]events.EventHandler{
-			events.EventAuctionEnded: auctionEndedEventHandler,
-			events.EventUserOutbid:   outbidEventHandler,
-		}
-		if err := subscriber.Listen(ctx, handlers); err != nil {
-			log.Printf("Event listener error: %v", err)
-		}
-	}()
-
-	go scheduler.Start(ctx)
+	consistencyChecker := scheduler.NewConsistencyChecker(bidService)
+	paymentDeadlineChecker := scheduler.NewPaymentDeadlineChecker(paymentService)
+	scheduler := scheduler.NewAuctionScheduler(auctionRepository, bidRepository, redis, closingQueue)
 
 	return &Provider{
 		HealthHandler:  healthHandler,
 		Config:         config,
 		UserHandler:    userHandler,
 		DB:             db,
+		Redis:          redis,
 		AuctionHandler: auctionHandler,
 		BidHandler:     bidHandler,
 		WsHandler:      wsHandler,
 		PaymentHandler: paymentHandler,
+		AdminHandler:   adminHandler,
+
+		FakeCheckoutHandler: fakeCheckoutHandler,
+
+		scheduler:   scheduler,
+		subscriber:  subscriber,
+		outboxRelay: outboxRelay,
+		consistency: consistencyChecker,
+		deadlines:   paymentDeadlineChecker,
+		connManager: wsConnManager,
+		eventHandlers: map[string]events.EventHandler{
+			events.EventAuctionEnded:      auctionEndedEventHandler,
+			events.EventUserOutbid:        outbidEventHandler,
+			events.EventAuctionExtended:   auctionExtendedEventHandler,
+			events.EventPaymentCompleted:  paymentCompletedEventHandler,
+			events.EventSecondChanceOffer: secondChanceOfferEventHandler,
+		},
+	}, nil
+}
+
+// Run starts the scheduler, the consistency checker, the payment deadline
+// checker, the outbox relay and the event listener. They stop once ctx is
+// cancelled; Wait blocks until they have.
+func (p *Provider) Run(ctx context.Context) error {
+	channels := make([]string, 0, len(p.eventHandlers))
+	for channel := range p.eventHandlers {
+		channels = append(channels, channel)
+	}
+
+	if err := p.subscriber.Subscribe(ctx, channels...); err != nil {
+		return fmt.Errorf("failed to subscribe to events: %w", err)
 	}
+
+	p.workers.Add(5)
+
+	go func() {
+		defer p.workers.Done()
+		if err := p.subscriber.Listen(ctx, p.eventHandlers); err != nil {
+			log.Printf("Event listener error: %v", err)
+		}
+	}()
+
+	go func() {
+		defer p.workers.Done()
+		p.scheduler.Start(ctx)
+	}()
+
+	go func() {
+		defer p.workers.Done()
+		p.outboxRelay.Start(ctx)
+	}()
+
+	go func() {
+		defer p.workers.Done()
+		p.consistency.Start(ctx)
+	}()
+
+	go func() {
+		defer p.workers.Done()
+		p.deadlines.Start(ctx)
+	}()
+
+	return nil
+}
+
+// Wait blocks until everything started by Run has finished its current work
+// and stopped, then closes the WebSocket connections, so clients hear about
+// anything handled during shutdown.
+func (p *Provider) Wait() {
+	p.workers.Wait()
+	p.connManager.CloseAll()
 }
@@ -22,6 +22,33 @@ func NewAuctionRepository(db *sql.DB) *AuctionRepository {
 	}
 }
 
+// auctionColumns are the columns read by auctionFields, qualified with the
+// "a" alias so queries can join other tables.
+const auctionColumns = `a.id, a.seller_id, a.title, a.description, a.currency, a.starting_price, a.current_price,
+	a.bid_increment, a.reserve_price, a.buy_now_price, a.soft_close_window_minutes, a.soft_close_extension_minutes,
+	a.status, a.start_time, a.end_time, a.created_at`
+
+func auctionFields(auction *domain.Auction) []any {
+	return []any{
+		&auction.ID,
+		&auction.SellerID,
+		&auction.Title,
+		&auction.Description,
+		&auction.Currency,
+		&auction.StartingPrice,
+		&auction.CurrentPrice,
+		&auction.BidIncrement,
+		&auction.ReservePrice,
+		&auction.BuyNowPrice,
+		&auction.SoftCloseWindow,
+		&auction.SoftCloseExtension,
+		&auction.Status,
+		&auction.StartTime,
+		&auction.EndTime,
+		&auction.CreatedAt,
+	}
+}
+
 func (r *AuctionRepository) CreateAuction(ctx context.Context, auction *domain.Auction, sellerID uuid.UUID, imageURLs []string) (*domain.Auction, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -30,11 +57,11 @@ func (r *AuctionRepository) CreateAuction(ctx context.Context, auction *domain.A
 	defer tx.Rollback()
 
 	query := `
-		INSERT INTO auctions (
-			seller_id, title, description, starting_price, current_price, status, start_time, end_time
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, seller_id, title, description, starting_price, current_price, status, start_time, end_time, created_at
-	`
+		INSERT INTO auctions AS a (
+			seller_id, title, description, currency, starting_price, current_price, bid_increment, reserve_price, buy_now_price,
+			soft_close_window_minutes, soft_close_extension_minutes, status, start_time, end_time
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING ` + auctionColumns
 
 	createdAuction := &domain.Auction{}
 
@@ -44,23 +71,18 @@ func (r *AuctionRepository) CreateAuction(ctx context.Context, auction *domain.A
 		sellerID,
 		auction.Title,
 		auction.Description,
+		auction.Currency,
 		auction.StartingPrice,
 		auction.CurrentPrice,
+		auction.BidIncrement,
+		auction.ReservePrice,
+		auction.BuyNowPrice,
+		auction.SoftCloseWindow,
+		auction.SoftCloseExtension,
 		auction.Status,
 		auction.StartTime,
 		auction.EndTime,
-	).Scan(
-		&createdAuction.ID,
-		&createdAuction.SellerID,
-		&createdAuction.Title,
-		&createdAuction.Description,
-		&createdAuction.StartingPrice,
-		&createdAuction.CurrentPrice,
-		&createdAuction.Status,
-		&createdAuction.StartTime,
-		&createdAuction.EndTime,
-		&createdAuction.CreatedAt,
-	)
+	).Scan(auctionFields(createdAuction)...)
 	if err != nil {
 		return nil, err
 	}
@@ -84,25 +106,10 @@ func (r *AuctionRepository) CreateAuction(ctx context.Context, auction *domain.A
 }
 
 func (r *AuctionRepository) GetAuction(ctx context.Context, auctionID uuid.UUID) (*domain.Auction, error) {
-	query := `
-		SELECT id, seller_id, title, description, starting_price, current_price, status, start_time, end_time, created_at
-		FROM auctions
-		WHERE id = $1
-	`
+	query := `SELECT ` + auctionColumns + ` FROM auctions a WHERE a.id = $1`
 
 	auction := &domain.Auction{}
-	err := r.db.QueryRowContext(ctx, query, auctionID).Scan(
-		&auction.ID,
-		&auction.SellerID,
-		&auction.Title,
-		&auction.Description,
-		&auction.StartingPrice,
-		&auction.CurrentPrice,
-		&auction.Status,
-		&auction.StartTime,
-		&auction.EndTime,
-		&auction.CreatedAt,
-	)
+	err := r.db.QueryRowContext(ctx, query, auctionID).Scan(auctionFields(auction)...)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
@@ -131,21 +138,46 @@ func (r *AuctionRepository) GetAuction(ctx context.Context, auctionID uuid.UUID)
 	return auction, nil
 }
 
+func (r *AuctionRepository) GetAuctionsByIDs(ctx context.Context, auctionIDs []uuid.UUID) ([]*domain.Auction, error) {
+	if len(auctionIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(auctionIDs))
+	for _, id := range auctionIDs {
+		ids = append(ids, id.String())
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+auctionColumns+` FROM auctions a WHERE a.id = ANY($1::uuid[])`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var auctions []*domain.Auction
+	for rows.Next() {
+		auction := &domain.Auction{}
+		if err := rows.Scan(auctionFields(auction)...); err != nil {
+			return nil, err
+		}
+		auctions = append(auctions, auction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return auctions, nil
+}
+
 func (r *AuctionRepository) GetUserAuctions(ctx context.Context, userID uuid.UUID, page, limit int) ([]*domain.Auction, int, error) {
 	offset := (page - 1) * limit
 
 	query := `
-		SELECT 
-			a.id,
-			a.seller_id,
-			a.title,
-			a.description,
-			a.starting_price,
-			a.current_price,
-			a.status,
-			a.start_time,
-			a.end_time,
-			a.created_at,
+		SELECT ` + auctionColumns + `,
 			COALESCE(ARRAY_AGG(ai.image_url) FILTER (WHERE ai.image_url IS NOT NULL), '{}') AS images
 		FROM auctions a
 		LEFT JOIN auction_images ai ON ai.auction_id = a.id
@@ -165,17 +197,7 @@ func (r *AuctionRepository) GetUserAuctions(ctx context.Context, userID uuid.UUI
 	for rows.Next() {
 		auction := &domain.Auction{}
 		err := rows.Scan(
-			&auction.ID,
-			&auction.SellerID,
-			&auction.Title,
-			&auction.Description,
-			&auction.StartingPrice,
-			&auction.CurrentPrice,
-			&auction.Status,
-			&auction.StartTime,
-			&auction.EndTime,
-			&auction.CreatedAt,
-			pq.Array(&auction.Images), // scan array of images
+			append(auctionFields(auction), pq.Array(&auction.Images))..., // scan array of images
 		)
 		if err != nil {
 			return nil, 0, err
@@ -193,14 +215,45 @@ func (r *AuctionRepository) GetUserAuctions(ctx context.Context, userID uuid.UUI
 	return auctions, total, nil
 }
 
-func (r *AuctionRepository) UpdateCurrentPrice(ctx context.Context, auctionID uuid.UUID, amount float64) error {
+// UpdateCurrentPrice raises current_price to amount. A price that is already
+// as high is left alone, so bids stored out of order can't lower it.
+func (r *AuctionRepository) UpdateCurrentPrice(ctx context.Context, auctionID uuid.UUID, amount domain.Amount) error {
 	_, err := r.db.ExecContext(ctx,
-		`UPDATE auctions SET current_price = $1 WHERE id = $2`,
+		`UPDATE auctions SET current_price = $1 WHERE id = $2 AND current_price < $1`,
 		amount, auctionID,
 	)
 	return err
 }
 
+// SyncCurrentPrice raises current_price to the highest bid on the auction
+// when it lags behind, and reports whether it did.
+func (r *AuctionRepository) SyncCurrentPrice(ctx context.Context, auctionID uuid.UUID) (bool, error) {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE auctions a
+		SET current_price = p.price
+		FROM (
+			SELECT COALESCE(MAX(b.amount), a2.starting_price) AS price
+			FROM auctions a2
+			LEFT JOIN bids b ON b.auction_id = a2.id
+			WHERE a2.id = $1
+			GROUP BY a2.starting_price
+		) p
+		WHERE a.id = $1 AND a.current_price < p.price
+		RETURNING a.id`,
+		auctionID,
+	).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (r *AuctionRepository) CloseAuction(ctx context.Context, auctionID uuid.UUID) error {
 	var id uuid.UUID
 	err := r.db.QueryRowContext(ctx,
@@ -215,14 +268,198 @@ func (r *AuctionRepository) CloseAuction(ctx context.Context, auctionID uuid.UUI
 	return err
 }
 
-func (r *AuctionRepository) GetEndedActiveAuctions(ctx context.Context, currentTime time.Time) ([]*domain.Auction, error) {
+func (r *AuctionRepository) UpdateAuctionStatus(ctx context.Context, auctionID uuid.UUID, status string) error {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE auctions SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING id`,
+		status, auctionID,
+	).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("auction with id %s not found", auctionID)
+	}
+
+	return err
+}
+
+// CloseWithSale ends an open auction at now, before its end time, recording
+// the sale as its winning bid along with the events it raises. It returns
+// ErrConflict when the auction is no longer open or has already ended.
+func (r *AuctionRepository) CloseWithSale(ctx context.Context, auctionID, buyerID uuid.UUID, price domain.Amount, now time.Time, outbox ...*domain.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`UPDATE auctions
+		SET status = 'closed', current_price = $1, end_time = $3, updated_at = NOW()
+		WHERE id = $2 AND status = 'open' AND $3 < end_time
+		RETURNING id`,
+		price, auctionID, now,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO bids (auction_id,bidder_id,amount) VALUES ($1,$2,$3)`,
+		auctionID, buyerID, price,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutbox(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ExtendEndTime moves the end of an open auction to endTime and stores the
+// events announcing it. It never brings the end forward, and reports whether
+// the end time changed; the events are only stored when it did.
+func (r *AuctionRepository) ExtendEndTime(ctx context.Context, auctionID uuid.UUID, endTime time.Time, outbox ...*domain.OutboxMessage) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`UPDATE auctions SET end_time = $1, updated_at = NOW()
+		WHERE id = $2 AND status = 'open' AND end_time < $1
+		RETURNING id`,
+		endTime, auctionID,
+	).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := insertOutbox(ctx, tx, outbox); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClaimEndedAuction moves an ended auction into 'closing' so only the caller
+// closes it. Auctions left in 'closing' for longer than lease are assumed to
+// belong to a dead replica and can be claimed again. The claim is identified
+// by the auction's ClosingToken, which CompleteClosing checks. It returns
+// ErrConflict when the auction isn't there to claim.
+func (r *AuctionRepository) ClaimEndedAuction(ctx context.Context, auctionID uuid.UUID, currentTime time.Time, lease time.Duration) (*domain.Auction, error) {
+	query := `
+		UPDATE auctions a SET status = 'closing', closing_started_at = NOW(), closing_token = gen_random_uuid(), updated_at = NOW()
+		WHERE a.id = $1 AND a.end_time <= $2
+			AND (a.status = 'open' OR (a.status = 'closing' AND a.closing_started_at < $3))
+		RETURNING ` + auctionColumns + `, a.closing_token`
+
+	auction := &domain.Auction{}
+	err := r.db.QueryRowContext(ctx, query, auctionID, currentTime, currentTime.Add(-lease)).Scan(append(auctionFields(auction), &auction.ClosingToken)...)
+	if err == sql.ErrNoRows {
+		return nil, ErrConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return auction, nil
+}
+
+// ClaimEndedAuctions claims up to limit ended auctions the same way as
+// ClaimEndedAuction. Rows another replica is claiming are skipped rather
+// than waited on.
+func (r *AuctionRepository) ClaimEndedAuctions(ctx context.Context, currentTime time.Time, lease time.Duration, limit int) ([]*domain.Auction, error) {
+	var auctions []*domain.Auction
+
+	query := `
+		UPDATE auctions a SET status = 'closing', closing_started_at = NOW(), closing_token = gen_random_uuid(), updated_at = NOW()
+		WHERE a.id IN (
+			SELECT id FROM auctions
+			WHERE end_time <= $1
+				AND (status = 'open' OR (status = 'closing' AND closing_started_at < $2))
+			ORDER BY end_time
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + auctionColumns + `, a.closing_token`
+
+	rows, err := r.db.QueryContext(ctx, query, currentTime, currentTime.Add(-lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var auction domain.Auction
+		if err := rows.Scan(append(auctionFields(&auction), &auction.ClosingToken)...); err != nil {
+			return nil, err
+		}
+		auctions = append(auctions, &auction)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return auctions, nil
+}
+
+// CompleteClosing moves a claimed auction to its final status and stores the
+// events announcing it. It returns ErrConflict if the auction is no longer
+// being closed under the claim identified by closingToken.
+func (r *AuctionRepository) CompleteClosing(ctx context.Context, auctionID, closingToken uuid.UUID, status string, outbox ...*domain.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`UPDATE auctions SET status = $1, closing_started_at = NULL, closing_token = NULL, updated_at = NOW()
+		WHERE id = $2 AND status = 'closing' AND closing_token = $3
+		RETURNING id`,
+		status, auctionID, closingToken,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutbox(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetActiveAuctions returns every open auction, ended or not.
+func (r *AuctionRepository) GetActiveAuctions(ctx context.Context) ([]*domain.Auction, error) {
 	var auctions []*domain.Auction
 
 	rows, err := r.db.QueryContext(ctx,
-		`SELECT id, seller_id, title, description, starting_price, current_price, status, start_time, end_time, created_at 
-         FROM auctions 
-         WHERE end_time <= $1 AND status = 'open'`,
-		currentTime,
+		`SELECT `+auctionColumns+`
+         FROM auctions a
+         WHERE a.status = 'open'`,
 	)
 	if err != nil {
 		return nil, err
@@ -231,19 +468,7 @@ func (r *AuctionRepository) GetEndedActiveAuctions(ctx context.Context, currentT
 
 	for rows.Next() {
 		var auction domain.Auction
-		err := rows.Scan(
-			&auction.ID,
-			&auction.SellerID,
-			&auction.Title,
-			&auction.Description,
-			&auction.StartingPrice,
-			&auction.CurrentPrice,
-			&auction.Status,
-			&auction.StartTime,
-			&auction.EndTime,
-			&auction.CreatedAt,
-		)
-		if err != nil {
+		if err := rows.Scan(auctionFields(&auction)...); err != nil {
 			return nil, err
 		}
 		auctions = append(auctions, &auction)
@@ -260,16 +485,7 @@ func (r *AuctionRepository) GetOpenAuctions(ctx context.Context, userID uuid.UUI
 	offset := (page - 1) * limit
 
 	query := `
-		SELECT 
-			a.id,
-			a.title,
-			a.description,
-			a.starting_price,
-			a.current_price,
-			a.status,
-			a.start_time,
-			a.end_time,
-			a.created_at,
+		SELECT ` + auctionColumns + `,
 			COALESCE(ARRAY_AGG(ai.image_url) FILTER (WHERE ai.image_url IS NOT NULL), '{}') AS images
 		FROM auctions a
 		LEFT JOIN auction_images ai ON ai.auction_id = a.id
@@ -289,16 +505,7 @@ func (r *AuctionRepository) GetOpenAuctions(ctx context.Context, userID uuid.UUI
 	for rows.Next() {
 		auction := &domain.Auction{}
 		err := rows.Scan(
-			&auction.ID,
-			&auction.Title,
-			&auction.Description,
-			&auction.StartingPrice,
-			&auction.CurrentPrice,
-			&auction.Status,
-			&auction.StartTime,
-			&auction.EndTime,
-			&auction.CreatedAt,
-			pq.Array(&auction.Images), // scan array of images
+			append(auctionFields(auction), pq.Array(&auction.Images))..., // scan array of images
 		)
 		if err != nil {
 			return nil, 0, err
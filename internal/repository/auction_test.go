@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestActiveAuctionsAndClosing(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	var sellerID uuid.UUID
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO users (email, password) VALUES ($1, 'x') RETURNING id`,
+		uuid.NewString()+"@example.com",
+	).Scan(&sellerID)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM users WHERE id = $1`, sellerID)
+	})
+
+	now := time.Now()
+	createAuction := func(endTime time.Time) uuid.UUID {
+		var id uuid.UUID
+		err := db.QueryRowContext(ctx,
+			`INSERT INTO auctions (seller_id, title, starting_price, current_price, status, start_time, end_time)
+			VALUES ($1, 'test', 100, 100, 'open', $2, $3) RETURNING id`,
+			sellerID, now.Add(-2*time.Hour), endTime,
+		).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create auction: %v", err)
+		}
+		return id
+	}
+	runningID := createAuction(now.Add(time.Hour))
+	endedID := createAuction(now.Add(-time.Hour))
+
+	repo := NewAuctionRepository(db)
+
+	active, err := repo.GetActiveAuctions(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveAuctions: %v", err)
+	}
+	found := map[uuid.UUID]bool{}
+	for _, auction := range active {
+		found[auction.ID] = true
+	}
+	if !found[runningID] || !found[endedID] {
+		t.Fatalf("active auctions don't include both open auctions")
+	}
+
+	claimed, err := repo.ClaimEndedAuction(ctx, endedID, now, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimEndedAuction: %v", err)
+	}
+	if claimed.ClosingToken == uuid.Nil {
+		t.Fatalf("claimed auction has no closing token")
+	}
+
+	if _, err := repo.ClaimEndedAuction(ctx, runningID, now, time.Minute); !errors.Is(err, ErrConflict) {
+		t.Fatalf("claiming a running auction returned %v, want ErrConflict", err)
+	}
+
+	err = repo.CompleteClosing(ctx, endedID, uuid.New(), domain.AuctionStatusClosed)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("completing with another claim's token returned %v, want ErrConflict", err)
+	}
+	if err := repo.CompleteClosing(ctx, endedID, claimed.ClosingToken, domain.AuctionStatusClosed); err != nil {
+		t.Fatalf("CompleteClosing: %v", err)
+	}
+}
@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
+	"github.com/aglili/auction-app/internal/domain"
 	"github.com/google/uuid"
 )
 
@@ -17,14 +19,303 @@ func NewBidRepository(db *sql.DB) *BidRepository {
 	}
 }
 
-func (r *BidRepository) CreateBid(ctx context.Context, auctionID, userID uuid.UUID, amount float64) error {
+// CreateBids stores the bids placed by a single bid request, including any
+// automatic bids, together with the bidder's proxy ceiling when one is given
+// and the events the bids raise.
+func (r *BidRepository) CreateBids(ctx context.Context, bids []*domain.Bid, proxy *domain.ProxyBid, outbox ...*domain.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertBids(ctx, tx, bids, proxy); err != nil {
+		return err
+	}
+
+	if err := insertOutbox(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PlaceBids places a bid request of amount in a single transaction. The
+// auction row is claimed with a conditional update that only matches while
+// the auction is open, hasn't ended by now and is priced below amount, which
+// also queues concurrent bids on the auction behind this one. resolve is
+// then given the highest bid and live proxies, and whatever it returns is
+// stored along with the new price. It returns ErrConflict when the auction can't
+// take the bid, and any error from resolve as is.
+func (r *BidRepository) PlaceBids(ctx context.Context, auctionID uuid.UUID, amount domain.Amount, now time.Time, resolve func(highest *domain.Bid, proxies []*domain.ProxyBid) (*domain.BidPlacement, error)) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`UPDATE auctions SET updated_at = NOW()
+		WHERE id = $1 AND status = 'open' AND $3 < end_time AND current_price < $2
+		RETURNING id`,
+		auctionID, amount, now,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	highest, err := highestBid(ctx, tx, auctionID)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	var proxies []*domain.ProxyBid
+	if highest != nil {
+		proxies, err = proxyBids(ctx, tx, auctionID, highest.Amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	placement, err := resolve(highest, proxies)
+	if err != nil {
+		return err
+	}
+
+	if err := insertBids(ctx, tx, placement.Bids, placement.Proxy); err != nil {
+		return err
+	}
+
+	if err := insertOutbox(ctx, tx, placement.Outbox); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE auctions SET current_price = $1 WHERE id = $2`,
+		placement.Price, auctionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertBids(ctx context.Context, tx *sql.Tx, bids []*domain.Bid, proxy *domain.ProxyBid) error {
 	query := `INSERT INTO bids
-	(auction_id,bidder_id,amount) 
-	VALUES ($1,$2,$3)
+	(auction_id,bidder_id,amount,is_auto)
+	VALUES ($1,$2,$3,$4)
+	`
+
+	for _, bid := range bids {
+		if _, err := tx.ExecContext(ctx, query, bid.AuctionID, bid.UserID, bid.Amount, bid.IsAuto); err != nil {
+			return err
+		}
+	}
+
+	if proxy != nil {
+		proxyQuery := `INSERT INTO proxy_bids
+		(auction_id,bidder_id,max_amount)
+		VALUES ($1,$2,$3)
+		ON CONFLICT (auction_id,bidder_id)
+		DO UPDATE SET max_amount = EXCLUDED.max_amount, updated_at = NOW()
+		`
+
+		if _, err := tx.ExecContext(ctx, proxyQuery, proxy.AuctionID, proxy.UserID, proxy.MaxAmount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *BidRepository) GetAuctionBids(ctx context.Context, auctionID uuid.UUID, cursor *domain.BidCursor, limit int) ([]*domain.Bid, error) {
+	query := `
+		SELECT id, auction_id, bidder_id, amount, created_at
+		FROM bids
+		WHERE auction_id = $1
+		ORDER BY amount DESC, created_at DESC, id DESC
+		LIMIT $2
 	`
+	args := []any{auctionID, limit}
 
-	_, err := r.db.ExecContext(ctx, query, auctionID, userID, amount)
+	if cursor != nil {
+		query = `
+			SELECT id, auction_id, bidder_id, amount, created_at
+			FROM bids
+			WHERE auction_id = $1 AND (amount, created_at, id) < ($3, $4, $5)
+			ORDER BY amount DESC, created_at DESC, id DESC
+			LIMIT $2
+		`
+		args = append(args, cursor.Amount, cursor.CreatedAt, cursor.ID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bids []*domain.Bid
+	for rows.Next() {
+		bid := &domain.Bid{}
+		if err := rows.Scan(&bid.ID, &bid.AuctionID, &bid.UserID, &bid.Amount, &bid.CreatedAt); err != nil {
+			return nil, err
+		}
+		bids = append(bids, bid)
+	}
 
-	return err
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return bids, nil
+}
+
+func (r *BidRepository) GetUserBids(ctx context.Context, userID uuid.UUID, page, limit int) ([]*domain.UserBid, int, error) {
+	offset := (page - 1) * limit
+
+	query := `
+		SELECT
+			b.auction_id,
+			MAX(b.amount) AS highest_bid,
+			MAX(b.created_at) AS last_bid_at,
+			COALESCE((
+				SELECT t.bidder_id = $1
+				FROM bids t
+				WHERE t.auction_id = b.auction_id
+				ORDER BY t.amount DESC, t.created_at ASC
+				LIMIT 1
+			), FALSE) AS is_leading
+		FROM bids b
+		WHERE b.bidder_id = $1
+		GROUP BY b.auction_id
+		ORDER BY last_bid_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var userBids []*domain.UserBid
+	for rows.Next() {
+		userBid := &domain.UserBid{}
+		if err := rows.Scan(&userBid.AuctionID, &userBid.HighestBid, &userBid.LastBidAt, &userBid.IsLeading); err != nil {
+			return nil, 0, err
+		}
+		userBids = append(userBids, userBid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	countQuery := `SELECT COUNT(DISTINCT auction_id) FROM bids WHERE bidder_id = $1`
+	var total int
+	if err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return userBids, total, nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// GetHighestBid returns the winning bid of an auction, or ErrNotFound when
+// nobody has bid. Between equal amounts the earlier bid wins.
+func (r *BidRepository) GetHighestBid(ctx context.Context, auctionID uuid.UUID) (*domain.Bid, error) {
+	return highestBid(ctx, r.db, auctionID)
+}
+
+func highestBid(ctx context.Context, q queryer, auctionID uuid.UUID) (*domain.Bid, error) {
+	bid := &domain.Bid{}
+
+	err := q.QueryRowContext(ctx,
+		`SELECT id, auction_id, bidder_id, amount, is_auto, created_at
+		FROM bids
+		WHERE auction_id = $1
+		ORDER BY amount DESC, created_at ASC
+		LIMIT 1`,
+		auctionID,
+	).Scan(&bid.ID, &bid.AuctionID, &bid.UserID, &bid.Amount, &bid.IsAuto, &bid.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bid, nil
+}
+
+// GetProxyBids returns the proxy ceilings of an auction that are still
+// above amount, and so can still bid.
+func (r *BidRepository) GetProxyBids(ctx context.Context, auctionID uuid.UUID, amount domain.Amount) ([]*domain.ProxyBid, error) {
+	return proxyBids(ctx, r.db, auctionID, amount)
+}
+
+func proxyBids(ctx context.Context, q queryer, auctionID uuid.UUID, amount domain.Amount) ([]*domain.ProxyBid, error) {
+	rows, err := q.QueryContext(ctx,
+		`SELECT auction_id, bidder_id, max_amount
+		FROM proxy_bids
+		WHERE auction_id = $1 AND max_amount > $2`,
+		auctionID, amount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var proxies []*domain.ProxyBid
+	for rows.Next() {
+		proxy := &domain.ProxyBid{}
+		if err := rows.Scan(&proxy.AuctionID, &proxy.UserID, &proxy.MaxAmount); err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, proxy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return proxies, nil
+}
+
+// GetNextBidder returns the highest bid of an auction from a bidder who
+// hasn't yet been asked to pay for it, or ErrNotFound when every bidder has.
+func (r *BidRepository) GetNextBidder(ctx context.Context, auctionID uuid.UUID) (*domain.Bid, error) {
+	bid := &domain.Bid{}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT b.id, b.auction_id, b.bidder_id, b.amount, b.is_auto, b.created_at
+		FROM bids b
+		WHERE b.auction_id = $1
+			AND NOT EXISTS (
+				SELECT 1 FROM payments p
+				WHERE p.auction_id = b.auction_id AND p.user_id = b.bidder_id
+			)
+		ORDER BY b.amount DESC, b.created_at ASC
+		LIMIT 1`,
+		auctionID,
+	).Scan(&bid.ID, &bid.AuctionID, &bid.UserID, &bid.Amount, &bid.IsAuto, &bid.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
 
+	return bid, nil
 }
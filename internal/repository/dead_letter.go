@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+type DeadLetterRepository struct {
+	db *sql.DB
+}
+
+func NewDeadLetterRepository(db *sql.DB) *DeadLetterRepository {
+	return &DeadLetterRepository{
+		db: db,
+	}
+}
+
+const deadLetterColumns = `id, event_id, channel, payload, error, attempts, created_at, replayed_at`
+
+func deadLetterFields(deadLetter *domain.DeadLetter) []any {
+	return []any{
+		&deadLetter.ID,
+		&deadLetter.EventID,
+		&deadLetter.Channel,
+		&deadLetter.Payload,
+		&deadLetter.Error,
+		&deadLetter.Attempts,
+		&deadLetter.CreatedAt,
+		&deadLetter.ReplayedAt,
+	}
+}
+
+func (r *DeadLetterRepository) CreateDeadLetter(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	query := `INSERT INTO dead_letters
+	(event_id,channel,payload,error,attempts)
+	VALUES ($1,$2,$3,$4,$5)
+	RETURNING id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		deadLetter.EventID,
+		deadLetter.Channel,
+		deadLetter.Payload,
+		deadLetter.Error,
+		deadLetter.Attempts,
+	).Scan(&deadLetter.ID, &deadLetter.CreatedAt)
+}
+
+func (r *DeadLetterRepository) GetDeadLetters(ctx context.Context, page, limit int) ([]*domain.DeadLetter, int, error) {
+	offset := (page - 1) * limit
+
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letters
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var deadLetters []*domain.DeadLetter
+	for rows.Next() {
+		deadLetter := &domain.DeadLetter{}
+		if err := rows.Scan(deadLetterFields(deadLetter)...); err != nil {
+			return nil, 0, err
+		}
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM dead_letters`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return deadLetters, total, nil
+}
+
+func (r *DeadLetterRepository) GetDeadLetter(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	deadLetter := &domain.DeadLetter{}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+deadLetterColumns+` FROM dead_letters WHERE id = $1`,
+		id,
+	).Scan(deadLetterFields(deadLetter)...)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
+
+// MarkReplayed records that a dead letter was published again. It returns
+// ErrConflict if it already was.
+func (r *DeadLetterRepository) MarkReplayed(ctx context.Context, id uuid.UUID) error {
+	var replayedID uuid.UUID
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE dead_letters SET replayed_at = NOW()
+		WHERE id = $1 AND replayed_at IS NULL
+		RETURNING id`,
+		id,
+	).Scan(&replayedID)
+
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+
+	return err
+}
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrDatabaseError = errors.New("database error")
+	ErrConflict      = errors.New("resource state changed")
 )
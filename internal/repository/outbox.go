@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// insertOutbox stores messages as part of tx, so they are only relayed if
+// the state change they describe commits.
+func insertOutbox(ctx context.Context, tx *sql.Tx, messages []*domain.OutboxMessage) error {
+	query := `INSERT INTO outbox
+	(id,channel,payload)
+	VALUES ($1,$2,$3)
+	`
+
+	for _, message := range messages {
+		if _, err := tx.ExecContext(ctx, query, message.ID, message.Channel, message.Payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ClaimPending returns up to limit undispatched messages, oldest first, and
+// hides them from other relays for lease. Messages a relay fails to mark
+// within the lease are claimed again.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxMessage, error) {
+	var messages []*domain.OutboxMessage
+
+	query := `
+		UPDATE outbox SET available_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE dispatched_at IS NULL AND available_at <= NOW()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, channel, payload, attempts, created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message := &domain.OutboxMessage{}
+		if err := rows.Scan(&message.ID, &message.Channel, &message.Payload, &message.Attempts, &message.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET dispatched_at = NOW(), last_error = NULL WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+// MarkFailed records a failed relay attempt and holds the message back
+// until retryAt.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $1, available_at = $2 WHERE id = $3`,
+		cause.Error(), retryAt, id,
+	)
+	return err
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{
+		db: db,
+	}
+}
+
+const paymentColumns = `id, auction_id, user_id, reference, amount, currency, status, COALESCE(authorization_url, ''), provider_payload, paid_at, expires_at, created_at, updated_at`
+
+func paymentFields(payment *domain.Payment) []any {
+	return []any{
+		&payment.ID,
+		&payment.AuctionID,
+		&payment.UserID,
+		&payment.Reference,
+		&payment.Amount,
+		&payment.Currency,
+		&payment.Status,
+		&payment.AuthorizationURL,
+		nullableJSON{&payment.ProviderPayload},
+		&payment.PaidAt,
+		&payment.ExpiresAt,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	}
+}
+
+// nullableJSON scans a JSONB column that may be NULL, such as the provider
+// payload of a payment the provider hasn't reported on yet. NULL is left as
+// a nil message.
+type nullableJSON struct {
+	dest *json.RawMessage
+}
+
+func (n nullableJSON) Scan(src any) error {
+	var value sql.Null[[]byte]
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+	*n.dest = value.V
+	return nil
+}
+
+func (r *PaymentRepository) CreatePayment(ctx context.Context, payment *domain.Payment) error {
+	query := `INSERT INTO payments
+	(auction_id,user_id,reference,amount,currency,authorization_url,expires_at)
+	VALUES ($1,$2,$3,$4,$5,$6,$7)
+	RETURNING ` + paymentColumns
+
+	return r.db.QueryRowContext(ctx, query,
+		payment.AuctionID,
+		payment.UserID,
+		payment.Reference,
+		payment.Amount,
+		payment.Currency,
+		payment.AuthorizationURL,
+		payment.ExpiresAt,
+	).Scan(paymentFields(payment)...)
+}
+
+func (r *PaymentRepository) GetPaymentByReference(ctx context.Context, reference string) (*domain.Payment, error) {
+	payment := &domain.Payment{}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+paymentColumns+` FROM payments WHERE reference = $1`,
+		reference,
+	).Scan(paymentFields(payment)...)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// GetPendingPayment returns the latest payment the user has yet to make for
+// an auction.
+func (r *PaymentRepository) GetPendingPayment(ctx context.Context, auctionID, userID uuid.UUID) (*domain.Payment, error) {
+	payment := &domain.Payment{}
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE auction_id = $1 AND user_id = $2 AND status = 'pending'
+		ORDER BY created_at DESC
+		LIMIT 1`,
+		auctionID, userID,
+	).Scan(paymentFields(payment)...)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// CompletePayment records a pending payment as paid, marks its auction paid
+// and stores the events announcing it. It returns ErrConflict if the payment
+// is no longer pending.
+func (r *PaymentRepository) CompletePayment(ctx context.Context, reference string, payload []byte, outbox ...*domain.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var auctionID uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`UPDATE payments SET status = 'success', provider_payload = $1, paid_at = NOW(), updated_at = NOW()
+		WHERE reference = $2 AND status = 'pending'
+		RETURNING auction_id`,
+		payload, reference,
+	).Scan(&auctionID)
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE auctions SET status = 'paid', updated_at = NOW() WHERE id = $1 AND status = 'closed'`,
+		auctionID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutbox(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FailPayment records a pending payment as failed. It returns ErrConflict if
+// the payment is no longer pending.
+func (r *PaymentRepository) FailPayment(ctx context.Context, reference string, payload []byte) error {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE payments SET status = 'failed', provider_payload = $1, updated_at = NOW()
+		WHERE reference = $2 AND status = 'pending'
+		RETURNING id`,
+		payload, reference,
+	).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+
+	return err
+}
+
+// ClaimRefund records that a charge on the payment is being refunded. It
+// returns ErrConflict if one already was, so it is only refunded once.
+func (r *PaymentRepository) ClaimRefund(ctx context.Context, reference string) error {
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE payments SET refunded_at = NOW(), updated_at = NOW()
+		WHERE reference = $1 AND refunded_at IS NULL
+		RETURNING id`,
+		reference,
+	).Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+
+	return err
+}
+
+// ReleaseRefund drops a refund claimed by ClaimRefund that the provider
+// didn't make, so it can be tried again.
+func (r *PaymentRepository) ReleaseRefund(ctx context.Context, reference string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE payments SET refunded_at = NULL, updated_at = NOW() WHERE reference = $1`,
+		reference,
+	)
+	return err
+}
+
+// GetOverduePayments returns up to limit pending payments whose deadline
+// passed before currentTime, oldest first.
+func (r *PaymentRepository) GetOverduePayments(ctx context.Context, currentTime time.Time, limit int) ([]*domain.Payment, error) {
+	var payments []*domain.Payment
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+paymentColumns+` FROM payments
+		WHERE status = 'pending' AND expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2`,
+		currentTime, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payment := &domain.Payment{}
+		if err := rows.Scan(paymentFields(payment)...); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// DefaultPayment records that a pending payment was never made and stores
+// the events that follow from it. It returns ErrConflict if the payment is
+// no longer pending, such as when it was paid at the last moment.
+func (r *PaymentRepository) DefaultPayment(ctx context.Context, reference string, outbox ...*domain.OutboxMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx,
+		`UPDATE payments SET status = 'defaulted', updated_at = NOW()
+		WHERE reference = $1 AND status = 'pending'
+		RETURNING id`,
+		reference,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutbox(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
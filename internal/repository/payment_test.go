@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testDB connects to the migrated database in TEST_DATABASE_URL, skipping
+// the test when it isn't set.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestPendingPaymentRoundTrip(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	var sellerID, buyerID, auctionID uuid.UUID
+	for _, id := range []*uuid.UUID{&sellerID, &buyerID} {
+		err := db.QueryRowContext(ctx,
+			`INSERT INTO users (email, password) VALUES ($1, 'x') RETURNING id`,
+			uuid.NewString()+"@example.com",
+		).Scan(id)
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM users WHERE id IN ($1, $2)`, sellerID, buyerID)
+	})
+
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO auctions (seller_id, title, starting_price, current_price, status, start_time, end_time)
+		VALUES ($1, 'test', 100, 100, 'closed', $2, $3) RETURNING id`,
+		sellerID, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour),
+	).Scan(&auctionID)
+	if err != nil {
+		t.Fatalf("failed to create auction: %v", err)
+	}
+
+	repo := NewPaymentRepository(db)
+
+	payment := &domain.Payment{
+		AuctionID:        auctionID,
+		UserID:           buyerID,
+		Reference:        "test-" + uuid.NewString(),
+		Amount:           15075,
+		Currency:         domain.CurrencyGHS,
+		AuthorizationURL: "https://checkout.example.com",
+		ExpiresAt:        time.Now().Add(-time.Minute),
+	}
+	if err := repo.CreatePayment(ctx, payment); err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	if payment.Status != domain.PaymentStatusPending || payment.ProviderPayload != nil {
+		t.Fatalf("created payment has status %q and payload %q, want pending with no payload", payment.Status, payment.ProviderPayload)
+	}
+
+	byReference, err := repo.GetPaymentByReference(ctx, payment.Reference)
+	if err != nil {
+		t.Fatalf("GetPaymentByReference: %v", err)
+	}
+	if byReference.Price() != payment.Price() {
+		t.Fatalf("stored price is %s, want %s", byReference.Price(), payment.Price())
+	}
+
+	if _, err := repo.GetPendingPayment(ctx, auctionID, buyerID); err != nil {
+		t.Fatalf("GetPendingPayment: %v", err)
+	}
+
+	overdue, err := repo.GetOverduePayments(ctx, time.Now(), 1000)
+	if err != nil {
+		t.Fatalf("GetOverduePayments: %v", err)
+	}
+	found := false
+	for _, p := range overdue {
+		found = found || p.Reference == payment.Reference
+	}
+	if !found {
+		t.Fatalf("overdue payments don't include %s", payment.Reference)
+	}
+
+	if err := repo.ClaimRefund(ctx, payment.Reference); err != nil {
+		t.Fatalf("ClaimRefund: %v", err)
+	}
+	if err := repo.ClaimRefund(ctx, payment.Reference); !errors.Is(err, ErrConflict) {
+		t.Fatalf("claiming a refund twice returned %v, want ErrConflict", err)
+	}
+	if err := repo.ReleaseRefund(ctx, payment.Reference); err != nil {
+		t.Fatalf("ReleaseRefund: %v", err)
+	}
+	if err := repo.ClaimRefund(ctx, payment.Reference); err != nil {
+		t.Fatalf("ClaimRefund after release: %v", err)
+	}
+
+	payload := []byte(`{"status":"success"}`)
+	if err := repo.CompletePayment(ctx, payment.Reference, payload); err != nil {
+		t.Fatalf("CompletePayment: %v", err)
+	}
+
+	completed, err := repo.GetPaymentByReference(ctx, payment.Reference)
+	if err != nil {
+		t.Fatalf("GetPaymentByReference after completion: %v", err)
+	}
+	// jsonb doesn't keep the payload's formatting, only its content
+	var stored map[string]string
+	if err := json.Unmarshal(completed.ProviderPayload, &stored); err != nil || stored["status"] != "success" {
+		t.Fatalf("completed payment has payload %s: %v", completed.ProviderPayload, err)
+	}
+	if completed.Status != domain.PaymentStatusSuccess {
+		t.Fatalf("completed payment has status %q, want success", completed.Status)
+	}
+}
@@ -43,19 +43,36 @@ func SetupRoutes(prov *provider.Provider) http.Handler {
 	protectedUser := v1.Group("/users")
 	protectedUser.Use(middleware.RequireUserAuth())
 	protectedUser.GET("/me", prov.UserHandler.GetUserProfile)
+	protectedUser.GET("/me/bids", prov.BidHandler.GetUserBids)
 	protectedUser.POST("/logout", prov.UserHandler.Logout)
 
 	auctions := v1.Group("/auctions")
 	auctions.Use(middleware.RequireUserAuth())
-	auctions.POST("", prov.AuctionHandler.CreateAuctionHandler)
+	idempotent := middleware.Idempotent(prov.Redis, prov.Config.IdempotencyKeyTTL)
+
+	auctions.POST("", idempotent, prov.AuctionHandler.CreateAuctionHandler)
 	auctions.GET("/me", prov.AuctionHandler.GetUserAuctions)
 	auctions.GET("/:id", prov.AuctionHandler.GetAuction)
-	auctions.POST("/:id/bid", prov.BidHandler.CreateBid)
+	auctions.POST("/:id/bid", idempotent, prov.BidHandler.CreateBid)
+	auctions.GET("/:id/bids", prov.BidHandler.GetAuctionBids)
+	auctions.POST("/:id/buy-now", prov.BidHandler.BuyNow)
 	auctions.GET("/ws", prov.WsHandler.HandleWSConnections)
 	auctions.GET("/open", prov.AuctionHandler.GetOpenAuctions)
 
+	admin := v1.Group("/admin")
+	admin.Use(middleware.RequireAdminKey(prov.Config.AdminAPIKey))
+	admin.GET("/dead-letters", prov.AdminHandler.GetDeadLetters)
+	admin.GET("/dead-letters/:id", prov.AdminHandler.GetDeadLetter)
+	admin.POST("/dead-letters/:id/replay", prov.AdminHandler.ReplayDeadLetter)
+
 	payments := v1.Group("/payments")
 	payments.POST("/webhook",prov.PaymentHandler.WebhookEndpoint)
+	payments.GET("/:reference/verify", middleware.RequireUserAuth(), prov.PaymentHandler.VerifyPayment)
+
+	// must match payment.FakeCheckoutPath
+	if prov.FakeCheckoutHandler != nil {
+		payments.GET("/fake/checkout/:reference", prov.FakeCheckoutHandler.Checkout)
+	}
 
 	return mux
 }
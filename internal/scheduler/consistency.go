@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+)
+
+// how often the cached bidding state is checked against the bids table
+const consistencyInterval = 5 * time.Minute
+
+// ConsistencyChecker warms the bidding state cache on startup and then
+// periodically repairs any drift between it and Postgres.
+type ConsistencyChecker struct {
+	biddingState domain.BiddingStateCache
+}
+
+func NewConsistencyChecker(biddingState domain.BiddingStateCache) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		biddingState: biddingState,
+	}
+}
+
+func (c *ConsistencyChecker) Start(ctx context.Context) {
+	log.Println("Starting consistency checker")
+
+	if err := c.biddingState.WarmBiddingState(ctx); err != nil {
+		log.Printf("Error warming bidding state: %v", err)
+	}
+
+	ticker := time.NewTicker(consistencyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping consistency checker")
+			return
+		case <-ticker.C:
+			if err := c.biddingState.ReconcileBiddingState(ctx); err != nil {
+				log.Printf("Error reconciling bidding state: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aglili/auction-app/internal/domain"
+)
+
+// how often payments are checked for having run out of time
+const paymentDeadlineInterval = time.Minute
+
+// PaymentDeadlineChecker periodically defaults winners who haven't paid
+// within the payment window, passing their auctions on to the next bidder.
+type PaymentDeadlineChecker struct {
+	deadlines domain.PaymentDeadlines
+}
+
+func NewPaymentDeadlineChecker(deadlines domain.PaymentDeadlines) *PaymentDeadlineChecker {
+	return &PaymentDeadlineChecker{
+		deadlines: deadlines,
+	}
+}
+
+func (c *PaymentDeadlineChecker) Start(ctx context.Context) {
+	log.Println("Starting payment deadline checker")
+
+	ticker := time.NewTicker(paymentDeadlineInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping payment deadline checker")
+			return
+		case <-ticker.C:
+			// a pass already under way finishes even when stopping
+			if err := c.deadlines.ExpireOverduePayments(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("Error expiring overdue payments: %v", err)
+			}
+		}
+	}
+}
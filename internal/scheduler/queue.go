@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ClosingQueue orders open auctions by end time so each one can be closed as
+// soon as it ends. It is a Redis sorted set scored by end time in
+// milliseconds, so every replica sees the same queue.
+type ClosingQueue struct {
+	client *redis.Client
+}
+
+func NewClosingQueue(client *redis.Client) *ClosingQueue {
+	return &ClosingQueue{
+		client: client,
+	}
+}
+
+// Schedule queues an auction to close at endTime, replacing any earlier
+// entry so end time changes take effect.
+func (q *ClosingQueue) Schedule(ctx context.Context, auctionID uuid.UUID, endTime time.Time) error {
+	err := q.client.ZAdd(ctx, cache.ClosingQueueKey, redis.Z{
+		Score:  float64(endTime.UnixMilli()),
+		Member: auctionID.String(),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to schedule auction %s: %w", auctionID, err)
+	}
+	return nil
+}
+
+// Unschedule removes an auction that was closed or cancelled early.
+func (q *ClosingQueue) Unschedule(ctx context.Context, auctionID uuid.UUID) error {
+	if err := q.client.ZRem(ctx, cache.ClosingQueueKey, auctionID.String()).Err(); err != nil {
+		return fmt.Errorf("failed to unschedule auction %s: %w", auctionID, err)
+	}
+	return nil
+}
+
+// Due returns up to limit auctions whose end time is at or before now,
+// earliest first.
+func (q *ClosingQueue) Due(ctx context.Context, now time.Time, limit int64) ([]uuid.UUID, error) {
+	members, err := q.client.ZRangeByScore(ctx, cache.ClosingQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: limit,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read closing queue: %w", err)
+	}
+
+	auctionIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		auctionID, err := uuid.Parse(member)
+		if err != nil {
+			// nothing can close it, so drop it rather than read it every tick
+			q.client.ZRem(ctx, cache.ClosingQueueKey, member)
+			continue
+		}
+		auctionIDs = append(auctionIDs, auctionID)
+	}
+
+	return auctionIDs, nil
+}
@@ -2,59 +2,166 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/aglili/auction-app/internal/cache"
 	"github.com/aglili/auction-app/internal/domain"
 	"github.com/aglili/auction-app/internal/events"
+	"github.com/aglili/auction-app/internal/repository"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	// how often the closing queue is checked for auctions that have ended
+	queuePollInterval = 500 * time.Millisecond
+	// how often Postgres is swept for ended auctions missing from the queue
+	sweepInterval = 1 * time.Minute
+	// most auctions closed from the queue or the sweep in a single pass
+	batchSize = 100
+	// how long a replica may hold an auction in 'closing' before another
+	// replica assumes it died and takes over
+	closingLease = 5 * time.Minute
+)
+
 type AuctionScheduler struct {
 	auctionRepo domain.AuctionRepository
+	bidRepo     domain.BidRepository
 	cache       *redis.Client
-	publisher   *events.EventPublisher
+	queue       *ClosingQueue
 }
 
-func NewAuctionScheduler(auctionRepo domain.AuctionRepository, cache *redis.Client, publisher *events.EventPublisher) *AuctionScheduler {
+func NewAuctionScheduler(auctionRepo domain.AuctionRepository, bidRepo domain.BidRepository, cache *redis.Client, queue *ClosingQueue) *AuctionScheduler {
 	return &AuctionScheduler{
 		auctionRepo: auctionRepo,
+		bidRepo:     bidRepo,
 		cache:       cache,
-		publisher:   publisher,
+		queue:       queue,
 	}
 }
 
 func (s *AuctionScheduler) Start(ctx context.Context) {
 	log.Println("Starting auction scheduler")
 
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	if err := s.rebuildQueue(ctx); err != nil {
+		log.Printf("Error rebuilding closing queue: %v", err)
+	}
+
+	pollTicker := time.NewTicker(queuePollInterval)
+	defer pollTicker.Stop()
 
-	s.checkAndCloseAuctions(ctx)
+	sweepTicker := time.NewTicker(sweepInterval)
+	defer sweepTicker.Stop()
+
+	// a pass that has started runs to the end even if ctx is cancelled, so
+	// no auction is left half closed on shutdown
+	passCtx := context.WithoutCancel(ctx)
+
+	s.checkAndCloseAuctions(passCtx)
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Stopping auction scheduler")
 			return
-		case <-ticker.C:
-			s.checkAndCloseAuctions(ctx)
+		case <-pollTicker.C:
+			s.closeDueAuctions(passCtx)
+		case <-sweepTicker.C:
+			s.checkAndCloseAuctions(passCtx)
+		}
+	}
+
+}
+
+// rebuildQueue queues every open auction from Postgres, so the queue is
+// complete even if Redis lost it or auctions were created while no
+// scheduler was running.
+func (s *AuctionScheduler) rebuildQueue(ctx context.Context) error {
+	auctions, err := s.auctionRepo.GetActiveAuctions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch open auctions: %w", err)
+	}
+
+	for _, auction := range auctions {
+		if err := s.queue.Schedule(ctx, auction.ID, auction.EndTime); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Queued %d open auctions for closing", len(auctions))
+	return nil
+}
+
+// closeDueAuctions closes the auctions the queue says have ended. Each one
+// is claimed in Postgres first, so when several replicas read the same
+// queue only one of them closes a given auction.
+func (s *AuctionScheduler) closeDueAuctions(ctx context.Context) {
+	now := time.Now()
+
+	auctionIDs, err := s.queue.Due(ctx, now, batchSize)
+	if err != nil {
+		log.Printf("Error fetching due auctions: %v", err)
+		return
+	}
+
+	for _, auctionID := range auctionIDs {
+		auction, err := s.auctionRepo.ClaimEndedAuction(ctx, auctionID, now, closingLease)
+		if errors.Is(err, repository.ErrConflict) {
+			s.reconcileQueued(ctx, auctionID, now)
+			continue
+		}
+		if err != nil {
+			log.Printf("Error claiming auction %s: %v", auctionID, err)
+			continue
+		}
+
+		if err := s.closeAuction(ctx, auction); err != nil {
+			log.Printf("Error closing auction %s: %v", auction.ID, err)
+		}
+	}
+}
+
+// reconcileQueued fixes the queue entry of a due auction that couldn't be
+// claimed because the queue was stale.
+func (s *AuctionScheduler) reconcileQueued(ctx context.Context, auctionID uuid.UUID, now time.Time) {
+	auction, err := s.auctionRepo.GetAuction(ctx, auctionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.unschedule(ctx, auctionID)
+			return
 		}
+		log.Printf("Error fetching auction %s: %v", auctionID, err)
+		return
 	}
 
+	switch {
+	case auction.Status == domain.AuctionStatusOpen && auction.EndTime.After(now):
+		// the end time moved after the auction was queued
+		if err := s.queue.Schedule(ctx, auction.ID, auction.EndTime); err != nil {
+			log.Printf("Error rescheduling auction %s: %v", auction.ID, err)
+		}
+	case auction.Status == domain.AuctionStatusClosing:
+		// another replica is closing it and will take it off the queue
+	default:
+		s.unschedule(ctx, auctionID)
+	}
 }
 
+// checkAndCloseAuctions sweeps Postgres for ended auctions the queue missed,
+// and for auctions a dead replica left half closed.
 func (s *AuctionScheduler) checkAndCloseAuctions(ctx context.Context) {
-	// Get all active auctions that have ended
-	auctions, err := s.auctionRepo.GetEndedActiveAuctions(ctx, time.Now())
+	auctions, err := s.auctionRepo.ClaimEndedAuctions(ctx, time.Now(), closingLease, batchSize)
 	if err != nil {
 		log.Printf("Error fetching ended auctions: %v", err)
 		return
 	}
 
-	log.Printf("Found %d auctions to close", len(auctions))
+	if len(auctions) > 0 {
+		log.Printf("Found %d auctions to close", len(auctions))
+	}
 
 	for _, auction := range auctions {
 		if err := s.closeAuction(ctx, auction); err != nil {
@@ -63,35 +170,42 @@ func (s *AuctionScheduler) checkAndCloseAuctions(ctx context.Context) {
 	}
 }
 
+func (s *AuctionScheduler) unschedule(ctx context.Context, auctionID uuid.UUID) {
+	if err := s.queue.Unschedule(ctx, auctionID); err != nil {
+		log.Printf("Error unscheduling auction %s: %v", auctionID, err)
+	}
+}
+
+// closeAuction settles an auction the caller has claimed and announces the
+// winner, if there is one.
 func (s *AuctionScheduler) closeAuction(ctx context.Context, auction *domain.Auction) error {
 	log.Printf("Closing auction %s", auction.ID)
 
-	// Get winner from Redis
-	bidderKey := fmt.Sprintf("auction:%s:highest_bidder", auction.ID.String())
-	priceKey := fmt.Sprintf("auction:%s:highest_bid", auction.ID.String())
-
-	winnerIDStr, err := s.cache.Get(ctx, bidderKey).Result()
-	if err == redis.Nil {
-		return s.auctionRepo.CloseAuction(ctx, auction.ID)
+	// the bids table decides the winner, so a lost cache can't lose it
+	winningBid, err := s.bidRepo.GetHighestBid(ctx, auction.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		if err := s.auctionRepo.CompleteClosing(ctx, auction.ID, auction.ClosingToken, domain.AuctionStatusClosed); err != nil {
+			return fmt.Errorf("failed to update auction status: %w", err)
+		}
+		s.clearBiddingState(ctx, auction.ID)
+		return nil
 	} else if err != nil {
 		return fmt.Errorf("failed to get winner: %w", err)
 	}
 
-	winnerID, err := uuid.Parse(winnerIDStr)
-	if err != nil {
-		return fmt.Errorf("invalid winner ID: %w", err)
-	}
+	winnerID := winningBid.UserID
+	finalPrice := winningBid.Amount
 
-	finalPriceStr, err := s.cache.Get(ctx, priceKey).Result()
-	if err != nil {
-		return fmt.Errorf("failed to get final price: %w", err)
-	}
+	// below the reserve the seller isn't obliged to sell, so there is no winner to announce
+	if !auction.ReserveMet(finalPrice) {
+		if err := s.auctionRepo.CompleteClosing(ctx, auction.ID, auction.ClosingToken, domain.AuctionStatusReserveNotMet); err != nil {
+			return fmt.Errorf("failed to update auction status: %w", err)
+		}
 
-	var finalPrice float64
-	fmt.Sscanf(finalPriceStr, "%f", &finalPrice)
+		s.clearBiddingState(ctx, auction.ID)
 
-	if err := s.auctionRepo.CloseAuction(ctx, auction.ID); err != nil {
-		return fmt.Errorf("failed to update auction status: %w", err)
+		log.Printf("Auction %s closed without a winner. Reserve not met at price %s", auction.ID, finalPrice)
+		return nil
 	}
 
 	event := events.AuctionEndedEvent{
@@ -101,12 +215,35 @@ func (s *AuctionScheduler) closeAuction(ctx context.Context, auction *domain.Auc
 		EndedAt:    time.Now(),
 	}
 
-	if err := s.publisher.PublishAuctionEnded(ctx, event); err != nil {
-		log.Printf("Failed to publish auction ended event: %v", err)
+	message, err := events.NewOutboxMessage(events.EventAuctionEnded, event)
+	if err != nil {
+		return err
 	}
 
-	s.cache.Del(ctx, bidderKey, priceKey)
+	// the event is stored with the status change, and a replica that lost
+	// its claim stores neither
+	if err := s.auctionRepo.CompleteClosing(ctx, auction.ID, auction.ClosingToken, domain.AuctionStatusClosed, message); err != nil {
+		return fmt.Errorf("failed to update auction status: %w", err)
+	}
 
-	log.Printf("Auction %s closed. Winner: %s, Price: %.2f", auction.ID, winnerID, finalPrice)
+	s.clearBiddingState(ctx, auction.ID)
+
+	log.Printf("Auction %s closed. Winner: %s, Price: %s", auction.ID, winnerID, finalPrice)
 	return nil
 }
+
+// clearBiddingState drops the cached bidding state of a closed auction and
+// takes it off the closing queue, leaving a marker behind so bids still in
+// flight are rejected.
+func (s *AuctionScheduler) clearBiddingState(ctx context.Context, auctionID uuid.UUID) {
+	s.unschedule(ctx, auctionID)
+
+	_, err := s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, cache.ClosedKey(auctionID), "1", cache.ClosedMarkerTTL)
+		pipe.Del(ctx, cache.AuctionKeys(auctionID)...)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to clear bidding state for auction %s: %v", auctionID, err)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"net/http"
 
 	"github.com/aglili/auction-app/internal/domain"
@@ -13,11 +14,13 @@ import (
 
 type AuctionService struct {
 	repository domain.AuctionRepository
+	queue      domain.AuctionQueue
 }
 
-func NewAuctionService(repository domain.AuctionRepository) *AuctionService {
+func NewAuctionService(repository domain.AuctionRepository, queue domain.AuctionQueue) *AuctionService {
 	return &AuctionService{
 		repository: repository,
+		queue:      queue,
 	}
 }
 
@@ -28,6 +31,11 @@ func (s *AuctionService) CreateAuction(ctx context.Context, auction *domain.Auct
 		return nil, err
 	}
 
+	// the scheduler's periodic sweep still closes the auction if this fails
+	if err := s.queue.Schedule(ctx, auction.ID, auction.EndTime); err != nil {
+		log.Printf("Failed to schedule auction %s: %v", auction.ID, err)
+	}
+
 	return auction, nil
 }
 
@@ -2,15 +2,23 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
 	"time"
 
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/aglili/auction-app/internal/config"
 	"github.com/aglili/auction-app/internal/domain"
+	"github.com/aglili/auction-app/internal/events"
 	"github.com/aglili/auction-app/internal/repository"
 	"github.com/aglili/auction-app/internal/utils"
+	"github.com/aglili/auction-app/pkg/constants"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
@@ -19,25 +27,188 @@ type BidService struct {
 	bidRepo     domain.BidRepository
 	auctionRepo domain.AuctionRepository
 	cache       *redis.Client
+	queue       domain.AuctionQueue
+	cfg         *config.Config
 }
 
-func NewBidService(bidRepo domain.BidRepository, auctionRepo domain.AuctionRepository, cache *redis.Client) *BidService {
+func NewBidService(bidRepo domain.BidRepository, auctionRepo domain.AuctionRepository, cache *redis.Client, queue domain.AuctionQueue, cfg *config.Config) *BidService {
 	return &BidService{
 		bidRepo:     bidRepo,
 		auctionRepo: auctionRepo,
 		cache:       cache,
+		queue:       queue,
+		cfg:         cfg,
 	}
 }
 
-func (s *BidService) CreateBid(ctx context.Context, auctionID, userID uuid.UUID, amount float64) error {
+func (s *BidService) CreateBid(ctx context.Context, auctionID, userID uuid.UUID, amount, maxAmount domain.Amount) (*domain.BidResult, error) {
 	auction, err := s.auctionRepo.GetAuction(ctx, auctionID)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			return utils.NewAppError(err, "auction not found", utils.ErrCodeNotFound, http.StatusNotFound)
+			return nil, utils.NewAppError(err, "auction not found", utils.ErrCodeNotFound, http.StatusNotFound)
 		}
+		return nil, utils.NewAppError(err, "failed to fetch auction", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	now := time.Now()
+	if err := checkBiddable(auction, userID); err != nil {
+		return nil, err
+	}
+
+	if maxAmount != 0 && maxAmount <= amount {
+		return nil, utils.NewAppError(nil, "max_amount must be greater than amount", utils.ErrCodeInvalidInput, http.StatusBadRequest)
+	}
+
+	if s.cfg.BidPlacementMode == constants.BID_PLACEMENT_POSTGRES {
+		return s.placeBidInTransaction(ctx, auction, userID, amount, maxAmount, now)
+	}
+
+	// state before and after the bid, captured by the script so outbid
+	// notifications go to whoever actually held the lead
+	previous, outcome, err := s.acceptBid(ctx, auction, userID, amount, maxAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	placement, err := newBidPlacement(auctionID, userID, maxAmount, previous, outcome, now)
+	if err != nil {
+		return nil, utils.NewAppError(err, "failed to save bid", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	if err := s.bidRepo.CreateBids(ctx, placement.Bids, placement.Proxy, placement.Outbox...); err != nil {
+		return nil, utils.NewAppError(err, "failed to save bid", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	if err := s.auctionRepo.UpdateCurrentPrice(ctx, auctionID, outcome.price); err != nil {
+		return nil, utils.NewAppError(err, "failed to update auction price", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	endTime := s.applySoftClose(ctx, auction, now)
+
+	return &domain.BidResult{
+		AuctionID:    auctionID,
+		CurrentPrice: outcome.price,
+		IsLeading:    outcome.leader == userID,
+		MaxAmount:    outcome.proxies[userID],
+		EndTime:      endTime,
+	}, nil
+}
+
+// placeBidInTransaction places a bid in a single Postgres transaction that
+// serialises bids on the auction row, rather than validating it against
+// the cache. The cached state is dropped afterwards and rebuilt on demand.
+func (s *BidService) placeBidInTransaction(ctx context.Context, auction *domain.Auction, userID uuid.UUID, amount, maxAmount domain.Amount, now time.Time) (*domain.BidResult, error) {
+	var outcome bidOutcome
+
+	err := s.bidRepo.PlaceBids(ctx, auction.ID, amount, now, func(highest *domain.Bid, proxies []*domain.ProxyBid) (*domain.BidPlacement, error) {
+		state := stateFromBids(auction, highest, proxies)
+
+		if err := checkMinimumBid(auction, state, amount); err != nil {
+			return nil, err
+		}
+
+		outcome = resolveBid(state, userID, amount, maxAmount, auction.MinimumIncrement)
+		return newBidPlacement(auction.ID, userID, maxAmount, state, outcome, now)
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, s.rejectedBidError(ctx, auction.ID, userID)
+	}
+	if err != nil {
+		if utils.IsAppError(err) {
+			return nil, err
+		}
+		return nil, utils.NewAppError(err, "failed to save bid", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	if err := s.cache.Del(ctx, cache.AuctionKeys(auction.ID)...).Err(); err != nil {
+		log.Printf("Failed to invalidate bidding state for auction %s: %v", auction.ID, err)
+	}
+
+	endTime := s.applySoftClose(ctx, auction, now)
+
+	return &domain.BidResult{
+		AuctionID:    auction.ID,
+		CurrentPrice: outcome.price,
+		IsLeading:    outcome.leader == userID,
+		MaxAmount:    outcome.proxies[userID],
+		EndTime:      endTime,
+	}, nil
+}
+
+// rejectedBidError explains why the auction row refused a bid: either the
+// auction stopped taking bids or its price moved past the bid.
+func (s *BidService) rejectedBidError(ctx context.Context, auctionID, userID uuid.UUID) error {
+	auction, err := s.auctionRepo.GetAuction(ctx, auctionID)
+	if err != nil {
 		return utils.NewAppError(err, "failed to fetch auction", utils.ErrCodeInternal, http.StatusInternalServerError)
 	}
 
+	if err := checkBiddable(auction, userID); err != nil {
+		return err
+	}
+
+	return minimumBidError(auction.MinimumBid())
+}
+
+func checkMinimumBid(auction *domain.Auction, state biddingState, amount domain.Amount) error {
+	minimumBid := state.highestBid + auction.MinimumIncrement(state.highestBid)
+	if amount < minimumBid {
+		return minimumBidError(minimumBid)
+	}
+	return nil
+}
+
+func minimumBidError(minimumBid domain.Amount) error {
+	return utils.NewAppError(fmt.Errorf("minimum bid is %s", minimumBid),
+		fmt.Sprintf("bid must be at least %s", minimumBid),
+		utils.ErrCodeNotAllowed, http.StatusBadRequest)
+}
+
+// newBidPlacement turns the outcome of a bid request into the rows to store,
+// including an outbid notification for everyone who lost the lead, so the
+// notifications are stored with the bids and can't be lost.
+func newBidPlacement(auctionID, userID uuid.UUID, maxAmount domain.Amount, previous biddingState, outcome bidOutcome, now time.Time) (*domain.BidPlacement, error) {
+	placement := &domain.BidPlacement{
+		Bids:  make([]*domain.Bid, 0, len(outcome.bids)),
+		Price: outcome.price,
+	}
+
+	for _, placed := range outcome.bids {
+		placement.Bids = append(placement.Bids, &domain.Bid{
+			AuctionID: auctionID,
+			UserID:    placed.userID,
+			Amount:    placed.amount,
+			IsAuto:    placed.isAuto,
+		})
+	}
+
+	if maxAmount != 0 {
+		placement.Proxy = &domain.ProxyBid{AuctionID: auctionID, UserID: userID, MaxAmount: max(maxAmount, previous.proxies[userID])}
+	}
+
+	for _, outbid := range outbidUsers(previous, outcome, userID) {
+		event := events.UserOutbidEvent{
+			AuctionID:    auctionID,
+			OutbidUserID: outbid.userID,
+			OldBid:       outbid.amount,
+			NewBid:       outcome.price,
+			NewBidderID:  outcome.leader,
+			OutbidAt:     now,
+		}
+
+		message, err := events.NewOutboxMessage(events.EventUserOutbid, event)
+		if err != nil {
+			return nil, err
+		}
+		placement.Outbox = append(placement.Outbox, message)
+	}
+
+	return placement, nil
+}
+
+// checkBiddable rejects bids and purchases on auctions that aren't running
+// or that belong to the user.
+func checkBiddable(auction *domain.Auction, userID uuid.UUID) error {
 	now := time.Now()
 	if now.Before(auction.StartTime) {
 		return utils.NewAppError(nil, "auction hasn't started yet", utils.ErrCodeForbidden, http.StatusForbidden)
@@ -50,66 +221,451 @@ func (s *BidService) CreateBid(ctx context.Context, auctionID, userID uuid.UUID,
 		return utils.NewAppError(nil, "cannot bid on own auction", utils.ErrCodeForbidden, http.StatusForbidden)
 	}
 
-	if auction.Status == "closed" {
+	if auction.Status != domain.AuctionStatusOpen {
 		return utils.NewAppError(nil, "auction has ended", utils.ErrCodeForbidden, http.StatusForbidden)
 	}
 
-	key := fmt.Sprintf("auction:%s:highest_bid", auctionID.String())
-	bidderKey := fmt.Sprintf("auction:%s:highest_bidder", auctionID.String())
+	return nil
+}
+
+// watchBiddingState runs fn in a WATCH transaction over an auction's bidding
+// state, retrying when a concurrent bid changes it first.
+func (s *BidService) watchBiddingState(ctx context.Context, auctionID uuid.UUID, fn func(tx *redis.Tx) error) error {
+	keys := append(cache.AuctionKeys(auctionID), cache.ClosedKey(auctionID))
 
-	// Use Redis WATCH for optimistic locking
+	var err error
 	maxRetries := 3
 	for i := 0; i < maxRetries; i++ {
-		err = s.cache.Watch(ctx, func(tx *redis.Tx) error {
-			highestBidStr, err := tx.Get(ctx, key).Result()
-			var highestBid float64
-
-			if err == redis.Nil {
-				highestBid = auction.StartingPrice
-			} else if err != nil {
-				return fmt.Errorf("failed to get highest bid: %w", err)
-			} else {
-				highestBid, err = strconv.ParseFloat(highestBidStr, 64)
-				if err != nil {
-					return fmt.Errorf("invalid highest bid format: %w", err)
-				}
+		err = s.cache.Watch(ctx, fn, keys...)
+		if err == nil {
+			return nil
+		}
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if utils.IsAppError(err) {
+			return err
+		}
+		return utils.NewAppError(err, "failed to update cache", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	return utils.NewAppError(err, "failed to place bid after retries", utils.ErrCodeInternal, http.StatusInternalServerError)
+}
+
+// loadBiddingState reads the highest bid, leader and proxy ceilings of an
+// auction inside a WATCH transaction. When the cache doesn't have them they
+// are rebuilt from Postgres, and the caller's write puts them back.
+func (s *BidService) loadBiddingState(ctx context.Context, tx *redis.Tx, auction *domain.Auction) (biddingState, error) {
+	closed, err := tx.Exists(ctx, cache.ClosedKey(auction.ID)).Result()
+	if err != nil {
+		return biddingState{}, fmt.Errorf("failed to check auction status: %w", err)
+	}
+	if closed > 0 {
+		return biddingState{}, utils.NewAppError(nil, "auction has ended", utils.ErrCodeForbidden, http.StatusForbidden)
+	}
+
+	state, cached, err := readCachedState(ctx, tx, auction)
+	if err != nil || cached {
+		return state, err
+	}
+
+	return s.storedBiddingState(ctx, auction)
+}
+
+// readCachedState reads the bidding state of an auction from the cache, and
+// reports whether the cache had it.
+func readCachedState(ctx context.Context, tx *redis.Tx, auction *domain.Auction) (biddingState, bool, error) {
+	state := biddingState{highestBid: auction.StartingPrice}
+
+	highestBidStr, err := tx.Get(ctx, cache.HighestBidKey(auction.ID)).Result()
+	if err == redis.Nil {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("failed to get highest bid: %w", err)
+	}
+	state.highestBid, err = parseAmount(highestBidStr)
+	if err != nil {
+		return state, false, fmt.Errorf("invalid highest bid format: %w", err)
+	}
+
+	highestBidderStr, err := tx.Get(ctx, cache.HighestBidderKey(auction.ID)).Result()
+	if err != nil && err != redis.Nil {
+		return state, false, fmt.Errorf("failed to get highest bidder: %w", err)
+	}
+	if highestBidderStr != "" {
+		state.leader, err = uuid.Parse(highestBidderStr)
+		if err != nil {
+			return state, false, fmt.Errorf("invalid highest bidder format: %w", err)
+		}
+	}
+
+	proxies, err := tx.HGetAll(ctx, cache.ProxyBidsKey(auction.ID)).Result()
+	if err != nil {
+		return state, false, fmt.Errorf("failed to get proxy bids: %w", err)
+	}
+
+	state.proxies = make(map[uuid.UUID]domain.Amount, len(proxies))
+	for bidder, ceiling := range proxies {
+		bidderID, err := uuid.Parse(bidder)
+		if err != nil {
+			return state, false, fmt.Errorf("invalid proxy bidder format: %w", err)
+		}
+		state.proxies[bidderID], err = parseAmount(ceiling)
+		if err != nil {
+			return state, false, fmt.Errorf("invalid proxy bid format: %w", err)
+		}
+	}
+
+	return state, true, nil
+}
+
+func writeBiddingState(ctx context.Context, pipe redis.Pipeliner, auctionID uuid.UUID, price domain.Amount, leader uuid.UUID, proxies map[uuid.UUID]domain.Amount) {
+	proxyKey := cache.ProxyBidsKey(auctionID)
+
+	pipe.Set(ctx, cache.HighestBidKey(auctionID), formatAmount(price), 0)
+	pipe.Set(ctx, cache.HighestBidderKey(auctionID), leader.String(), 0)
+	pipe.Del(ctx, proxyKey)
+	for bidder, ceiling := range proxies {
+		pipe.HSet(ctx, proxyKey, bidder.String(), formatAmount(ceiling))
+	}
+}
+
+// outbidUsers lists everyone who held the lead during a bid request but lost
+// it, along with the last amount they bid. That is the previous leader when
+// the bidder took over, or the bidder themselves when a proxy beat them.
+func outbidUsers(previous biddingState, outcome bidOutcome, userID uuid.UUID) []placedBid {
+	var outbid []placedBid
+
+	if previous.leader != uuid.Nil && previous.leader != outcome.leader {
+		last := placedBid{userID: previous.leader, amount: previous.highestBid}
+		for _, placed := range outcome.bids {
+			if placed.userID == previous.leader {
+				last = placed
 			}
+		}
+		outbid = append(outbid, last)
+	}
 
-			// Simple validation: bid must be higher than current highest
-			if amount <= highestBid {
-				return utils.NewAppError(nil,
-					fmt.Sprintf("bid must be higher than current highest bid of %.2f", highestBid),
-					utils.ErrCodeNotAllowed, http.StatusBadRequest)
+	if userID != outcome.leader {
+		var last placedBid
+		for _, placed := range outcome.bids {
+			if placed.userID == userID {
+				last = placed
 			}
+		}
+		outbid = append(outbid, last)
+	}
+
+	return outbid
+}
+
+// BuyNow sells an auction to the user at its buy-now price and ends it
+// straight away. The sale is announced exactly like a scheduled close.
+func (s *BidService) BuyNow(ctx context.Context, auctionID, userID uuid.UUID) (*domain.BidResult, error) {
+	auction, err := s.auctionRepo.GetAuction(ctx, auctionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, utils.NewAppError(err, "auction not found", utils.ErrCodeNotFound, http.StatusNotFound)
+		}
+		return nil, utils.NewAppError(err, "failed to fetch auction", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	if err := checkBiddable(auction, userID); err != nil {
+		return nil, err
+	}
 
-			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-				pipe.Set(ctx, key, amount, 0)
-				pipe.Set(ctx, bidderKey, userID.String(), 0)
-				return nil
-			})
+	if auction.BuyNowPrice == nil {
+		return nil, utils.NewAppError(nil, "auction has no buy now price", utils.ErrCodeNotAllowed, http.StatusBadRequest)
+	}
+	price := *auction.BuyNowPrice
+
+	var previous biddingState
+
+	// claim the auction in the cache first so no bid can sneak in while the sale is stored
+	err = s.watchBiddingState(ctx, auctionID, func(tx *redis.Tx) error {
+		state, err := s.loadBiddingState(ctx, tx, auction)
+		if err != nil {
 			return err
-		}, key)
+		}
 
-		if err == nil {
-			break
+		if state.leader != uuid.Nil && int64(state.highestBid)*100 > int64(price)*s.cfg.BuyNowDisablePercent {
+			return utils.NewAppError(nil, "buy now is no longer available", utils.ErrCodeNotAllowed, http.StatusForbidden)
 		}
-		if err == redis.TxFailedErr {
-			continue
+
+		previous = state
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			writeBiddingState(ctx, pipe, auctionID, price, userID, nil)
+			pipe.Set(ctx, cache.ClosedKey(auctionID), userID.String(), cache.ClosedMarkerTTL)
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	outbox, err := buyNowOutbox(auctionID, userID, price, previous, now)
+	if err != nil {
+		s.restoreBiddingState(ctx, auctionID, previous)
+		return nil, utils.NewAppError(err, "failed to complete purchase", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	if err := s.auctionRepo.CloseWithSale(ctx, auctionID, userID, price, now, outbox...); err != nil {
+		s.restoreBiddingState(ctx, auctionID, previous)
+
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, utils.NewAppError(err, "auction has ended", utils.ErrCodeForbidden, http.StatusForbidden)
 		}
-		return utils.NewAppError(err, "failed to update cache", utils.ErrCodeInternal, http.StatusInternalServerError)
+		return nil, utils.NewAppError(err, "failed to complete purchase", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	s.cache.Del(ctx, cache.AuctionKeys(auctionID)...)
+
+	if err := s.queue.Unschedule(ctx, auctionID); err != nil {
+		log.Printf("Failed to unschedule auction %s: %v", auctionID, err)
 	}
 
+	return &domain.BidResult{
+		AuctionID:    auctionID,
+		CurrentPrice: price,
+		IsLeading:    true,
+		EndTime:      now,
+	}, nil
+}
+
+// buyNowOutbox builds the events a buy-now sale raises: the same auction
+// ended event as a scheduled close, and an outbid event for whoever was
+// leading before the sale, which was made at now.
+func buyNowOutbox(auctionID, buyerID uuid.UUID, price domain.Amount, previous biddingState, now time.Time) ([]*domain.OutboxMessage, error) {
+	ended, err := events.NewOutboxMessage(events.EventAuctionEnded, events.AuctionEndedEvent{
+		AuctionID:  auctionID,
+		WinnerID:   buyerID,
+		FinalPrice: price,
+		EndedAt:    now,
+	})
 	if err != nil {
-		return utils.NewAppError(err, "failed to place bid after retries", utils.ErrCodeInternal, http.StatusInternalServerError)
+		return nil, err
 	}
+	outbox := []*domain.OutboxMessage{ended}
 
-	if err := s.bidRepo.CreateBid(ctx, auctionID, userID, amount); err != nil {
-		return utils.NewAppError(err, "failed to save bid", utils.ErrCodeInternal, http.StatusInternalServerError)
+	if previous.leader != uuid.Nil && previous.leader != buyerID {
+		outbid, err := events.NewOutboxMessage(events.EventUserOutbid, events.UserOutbidEvent{
+			AuctionID:    auctionID,
+			OutbidUserID: previous.leader,
+			OldBid:       previous.highestBid,
+			NewBid:       price,
+			NewBidderID:  buyerID,
+			OutbidAt:     now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		outbox = append(outbox, outbid)
 	}
 
-	if err := s.auctionRepo.UpdateCurrentPrice(ctx, auctionID, amount); err != nil {
-		return utils.NewAppError(err, "failed to update auction price", utils.ErrCodeInternal, http.StatusInternalServerError)
+	return outbox, nil
+}
+
+// applySoftClose extends the auction when a bid placed at bidTime falls in
+// its soft close window, and stores an event telling connected clients about
+// the new end time along with it. The bid is already stored, so failures are
+// logged rather than returned.
+func (s *BidService) applySoftClose(ctx context.Context, auction *domain.Auction, bidTime time.Time) time.Time {
+	endTime, extend := auction.SoftCloseEndTime(bidTime)
+	if !extend {
+		return auction.EndTime
 	}
 
-	return nil
+	message, err := events.NewOutboxMessage(events.EventAuctionExtended, events.AuctionExtendedEvent{
+		AuctionID:  auction.ID,
+		EndTime:    endTime,
+		ExtendedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to extend auction %s: %v", auction.ID, err)
+		return auction.EndTime
+	}
+
+	extended, err := s.auctionRepo.ExtendEndTime(ctx, auction.ID, endTime, message)
+	if err != nil {
+		log.Printf("Failed to extend auction %s: %v", auction.ID, err)
+		return auction.EndTime
+	}
+	if !extended {
+		// a concurrent bid already pushed the end time back
+		return endTime
+	}
+
+	if err := s.queue.Schedule(ctx, auction.ID, endTime); err != nil {
+		log.Printf("Failed to reschedule auction %s: %v", auction.ID, err)
+	}
+
+	return endTime
+}
+
+// restoreBiddingState puts back the cached state of an auction when a sale
+// claimed in the cache could not be stored.
+func (s *BidService) restoreBiddingState(ctx context.Context, auctionID uuid.UUID, state biddingState) {
+	_, err := s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, cache.ClosedKey(auctionID))
+		if state.leader == uuid.Nil {
+			pipe.Del(ctx, cache.AuctionKeys(auctionID)...)
+			return nil
+		}
+		writeBiddingState(ctx, pipe, auctionID, state.highestBid, state.leader, state.proxies)
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to restore bidding state for auction %s: %v", auctionID, err)
+	}
+}
+
+func (s *BidService) GetAuctionBids(ctx context.Context, auctionID uuid.UUID, cursor string, limit int) ([]*domain.BidHistoryEntry, string, error) {
+	if _, err := s.auctionRepo.GetAuction(ctx, auctionID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, "", utils.NewAppError(err, "auction not found", utils.ErrCodeNotFound, http.StatusNotFound)
+		}
+		return nil, "", utils.NewAppError(err, "failed to fetch auction", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	var after *domain.BidCursor
+	if cursor != "" {
+		decoded, err := decodeBidCursor(cursor)
+		if err != nil {
+			return nil, "", utils.NewAppError(err, "invalid cursor", utils.ErrCodeInvalidInput, http.StatusBadRequest)
+		}
+		after = decoded
+	}
+
+	// fetch one extra row to know whether another page exists
+	bids, err := s.bidRepo.GetAuctionBids(ctx, auctionID, after, limit+1)
+	if err != nil {
+		return nil, "", utils.NewAppError(err, "failed to fetch bids", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	nextCursor := ""
+	if len(bids) > limit {
+		bids = bids[:limit]
+		last := bids[len(bids)-1]
+		nextCursor, err = encodeBidCursor(&domain.BidCursor{Amount: last.Amount, CreatedAt: last.CreatedAt, ID: last.ID})
+		if err != nil {
+			return nil, "", utils.NewAppError(err, "failed to build cursor", utils.ErrCodeInternal, http.StatusInternalServerError)
+		}
+	}
+
+	history := make([]*domain.BidHistoryEntry, 0, len(bids))
+	for _, bid := range bids {
+		history = append(history, &domain.BidHistoryEntry{
+			Amount:    bid.Amount,
+			Bidder:    bidderHandle(auctionID, bid.UserID),
+			CreatedAt: bid.CreatedAt,
+		})
+	}
+
+	return history, nextCursor, nil
+}
+
+func (s *BidService) GetUserBids(ctx context.Context, userID uuid.UUID, page, limit int) ([]*domain.UserBidSummary, int, error) {
+	userBids, total, err := s.bidRepo.GetUserBids(ctx, userID, page, limit)
+	if err != nil {
+		return nil, 0, utils.NewAppError(err, "failed to fetch bids", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	auctionIDs := make([]uuid.UUID, 0, len(userBids))
+	for _, userBid := range userBids {
+		auctionIDs = append(auctionIDs, userBid.AuctionID)
+	}
+
+	auctions, err := s.auctionRepo.GetAuctionsByIDs(ctx, auctionIDs)
+	if err != nil {
+		return nil, 0, utils.NewAppError(err, "failed to fetch auctions", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	auctionsByID := make(map[uuid.UUID]*domain.Auction, len(auctions))
+	for _, auction := range auctions {
+		auctionsByID[auction.ID] = auction
+	}
+
+	summaries := make([]*domain.UserBidSummary, 0, len(userBids))
+	for _, userBid := range userBids {
+		auction, ok := auctionsByID[userBid.AuctionID]
+		if !ok {
+			continue
+		}
+
+		summaries = append(summaries, &domain.UserBidSummary{
+			AuctionID:     auction.ID,
+			Title:         auction.Title,
+			HighestBid:    userBid.HighestBid,
+			CurrentPrice:  auction.CurrentPrice,
+			IsLeading:     userBid.IsLeading,
+			AuctionStatus: auction.Status,
+			BidStatus:     bidStatus(auction.Status, userBid.IsLeading),
+			EndTime:       auction.EndTime,
+			LastBidAt:     userBid.LastBidAt,
+		})
+	}
+
+	return summaries, total, nil
+}
+
+// bidStatus describes where a bid stands for the user who placed it, given
+// the status of its auction and whether it is the auction's highest bid.
+func bidStatus(auctionStatus string, isLeading bool) string {
+	switch auctionStatus {
+	case domain.AuctionStatusOpen, domain.AuctionStatusClosing:
+		if isLeading {
+			return "winning"
+		}
+		return "outbid"
+	case domain.AuctionStatusClosed, domain.AuctionStatusPaid:
+		if isLeading {
+			return "won"
+		}
+		return "lost"
+	case domain.AuctionStatusUnpaid:
+		// the winner never paid and nobody took the auction after them
+		if isLeading {
+			return "unpaid"
+		}
+		return "lost"
+	case domain.AuctionStatusReserveNotMet:
+		return "reserve_not_met"
+	case domain.AuctionStatusCancelled:
+		return "cancelled"
+	default:
+		return auctionStatus
+	}
+}
+
+// bidderHandle gives a bidder a stable name within one auction that can't be
+// linked to the same user on other auctions.
+func bidderHandle(auctionID, userID uuid.UUID) string {
+	sum := sha256.Sum256(append(auctionID[:], userID[:]...))
+	return "bidder-" + hex.EncodeToString(sum[:4])
+}
+
+func encodeBidCursor(cursor *domain.BidCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeBidCursor(cursor string) (*domain.BidCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := &domain.BidCursor{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
 }
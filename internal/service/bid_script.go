@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// acceptBidScript validates a bid against the cached bidding state of an
+// auction and applies it, proxy bids included, in one atomic step, so
+// concurrent bids queue up in Redis instead of failing a WATCH.
+//
+// KEYS: highest bid, highest bidder, proxy bids, closed marker.
+// ARGV: bidder, amount, max amount (0 for none), end time in unix
+// milliseconds, the number of increment tiers followed by each tier's from
+// and increment, and optionally "seed" followed by the highest bid, leader,
+// number of proxies and each proxy's bidder and ceiling.
+//
+// The seed is the state stored in Postgres. It is only used when the cache
+// has no state for the auction; without one the script returns "miss".
+//
+// Otherwise it returns "closed", "ended", {"low", minimum bid}, or "ok"
+// followed by the previous highest bid, previous leader, the bidder's
+// previous proxy ceiling, the new price, new leader, the bidder's new proxy
+// ceiling and then the bidder, amount and auto flag of every bid placed.
+//
+// Amounts are whole minor units, which Lua numbers hold exactly, so no
+// rounding is needed. Proxy resolution mirrors resolveBid; a change to one
+// must be made to both, and TestAcceptBidScriptMatchesResolveBid checks
+// that they agree.
+var acceptBidScript = redis.NewScript(`
+local function format(amount)
+	return string.format('%d', amount)
+end
+
+if redis.call('EXISTS', KEYS[4]) == 1 then
+	return {'closed'}
+end
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+if now > tonumber(ARGV[4]) then
+	return {'ended'}
+end
+
+local user = ARGV[1]
+local amount = tonumber(ARGV[2])
+local max_amount = tonumber(ARGV[3])
+
+local arg = 5
+local tiers = {}
+for _ = 1, tonumber(ARGV[arg]) do
+	table.insert(tiers, {tonumber(ARGV[arg + 1]), tonumber(ARGV[arg + 2])})
+	arg = arg + 2
+end
+arg = arg + 1
+
+local function increment(price)
+	local inc = tiers[1][2]
+	for _, tier in ipairs(tiers) do
+		if price >= tier[1] then
+			inc = tier[2]
+		end
+	end
+	return inc
+end
+
+local highest = redis.call('GET', KEYS[1])
+local leader
+local proxies = {}
+if highest then
+	highest = tonumber(highest)
+	leader = redis.call('GET', KEYS[2]) or ''
+	local cached = redis.call('HGETALL', KEYS[3])
+	for i = 1, #cached, 2 do
+		proxies[cached[i]] = tonumber(cached[i + 1])
+	end
+elseif ARGV[arg] == 'seed' then
+	highest = tonumber(ARGV[arg + 1])
+	leader = ARGV[arg + 2]
+	arg = arg + 3
+	for _ = 1, tonumber(ARGV[arg]) do
+		proxies[ARGV[arg + 1]] = tonumber(ARGV[arg + 2])
+		arg = arg + 2
+	end
+else
+	return {'miss'}
+end
+
+local minimum = highest + increment(highest)
+if amount < minimum then
+	return {'low', format(minimum)}
+end
+
+local previous_ceiling = proxies[user] or 0
+if max_amount > amount and max_amount > previous_ceiling then
+	proxies[user] = max_amount
+end
+
+local bids = {{user, amount, '0'}}
+local price = amount
+local new_leader = user
+
+-- only the leader can hold a proxy above the highest bid, so a bid is only
+-- ever contested by the leader's proxy
+local defender = leader
+if defender ~= '' and defender ~= user then
+	local ceiling = math.max(amount, proxies[user] or 0)
+	local defender_ceiling = proxies[defender] or 0
+
+	if defender_ceiling > amount then
+		if defender_ceiling >= ceiling then
+			if ceiling > amount and ceiling < defender_ceiling then
+				table.insert(bids, {user, ceiling, '1'})
+			end
+			price = math.min(defender_ceiling, ceiling + increment(ceiling))
+			new_leader = defender
+			table.insert(bids, {defender, price, '1'})
+		else
+			table.insert(bids, {defender, defender_ceiling, '1'})
+			price = math.min(ceiling, defender_ceiling + increment(defender_ceiling))
+			table.insert(bids, {user, price, '1'})
+		end
+	end
+end
+
+redis.call('SET', KEYS[1], format(price))
+redis.call('SET', KEYS[2], new_leader)
+redis.call('DEL', KEYS[3])
+for bidder, ceiling in pairs(proxies) do
+	if ceiling > price then
+		redis.call('HSET', KEYS[3], bidder, format(ceiling))
+	else
+		proxies[bidder] = nil
+	end
+end
+
+local result = {'ok', format(highest), leader, format(previous_ceiling), format(price), new_leader, format(proxies[user] or 0)}
+for _, bid in ipairs(bids) do
+	table.insert(result, bid[1])
+	table.insert(result, format(bid[2]))
+	table.insert(result, bid[3])
+end
+return result
+`)
+
+// acceptBid places a bid on the cached bidding state of an auction and
+// returns the state before and after it. When the cache is missing the
+// state, it is seeded from Postgres by the same script run.
+func (s *BidService) acceptBid(ctx context.Context, auction *domain.Auction, userID uuid.UUID, amount, maxAmount domain.Amount) (biddingState, bidOutcome, error) {
+	keys := append(cache.AuctionKeys(auction.ID), cache.ClosedKey(auction.ID))
+
+	args := []interface{}{userID.String(), formatAmount(amount), formatAmount(maxAmount), auction.EndTime.UnixMilli()}
+
+	tiers := auction.IncrementTiers()
+	args = append(args, len(tiers))
+	for _, tier := range tiers {
+		args = append(args, formatAmount(tier.From), formatAmount(tier.Increment))
+	}
+
+	reply, err := acceptBidScript.Run(ctx, s.cache, keys, args...).StringSlice()
+	if err == nil && reply[0] == "miss" {
+		var state biddingState
+		state, err = s.storedBiddingState(ctx, auction)
+		if err != nil {
+			return biddingState{}, bidOutcome{}, utils.NewAppError(err, "failed to load bidding state", utils.ErrCodeInternal, http.StatusInternalServerError)
+		}
+
+		reply, err = acceptBidScript.Run(ctx, s.cache, keys, append(args, seedArgs(state)...)...).StringSlice()
+	}
+	if err != nil {
+		return biddingState{}, bidOutcome{}, utils.NewAppError(err, "failed to update cache", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	switch reply[0] {
+	case "closed", "ended":
+		return biddingState{}, bidOutcome{}, utils.NewAppError(nil, "auction has ended", utils.ErrCodeForbidden, http.StatusForbidden)
+	case "low":
+		minimumBid, err := parseAmount(reply[1])
+		if err != nil {
+			return biddingState{}, bidOutcome{}, utils.NewAppError(err, "failed to place bid", utils.ErrCodeInternal, http.StatusInternalServerError)
+		}
+		return biddingState{}, bidOutcome{}, minimumBidError(minimumBid)
+	}
+
+	previous, outcome, err := parseAcceptedBid(userID, reply)
+	if err != nil {
+		return biddingState{}, bidOutcome{}, utils.NewAppError(err, "failed to place bid", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	return previous, outcome, nil
+}
+
+// seedArgs encodes the bidding state of an auction for acceptBidScript.
+func seedArgs(state biddingState) []interface{} {
+	leader := ""
+	if state.leader != uuid.Nil {
+		leader = state.leader.String()
+	}
+
+	args := []interface{}{"seed", formatAmount(state.highestBid), leader, len(state.proxies)}
+	for bidder, ceiling := range state.proxies {
+		args = append(args, bidder.String(), formatAmount(ceiling))
+	}
+	return args
+}
+
+// parseAcceptedBid decodes the reply to a bid accepted by acceptBidScript.
+// Only the bidder's own proxy ceilings are returned, which is all a caller
+// needs to store the bid.
+func parseAcceptedBid(userID uuid.UUID, reply []string) (biddingState, bidOutcome, error) {
+	if len(reply) < 7 || (len(reply)-7)%3 != 0 {
+		return biddingState{}, bidOutcome{}, fmt.Errorf("unexpected bid script reply: %v", reply)
+	}
+
+	var amounts [4]domain.Amount
+	for i, field := range []string{reply[1], reply[3], reply[4], reply[6]} {
+		amount, err := parseAmount(field)
+		if err != nil {
+			return biddingState{}, bidOutcome{}, fmt.Errorf("invalid amount in bid script reply: %w", err)
+		}
+		amounts[i] = amount
+	}
+
+	previousLeader, err := parseLeader(reply[2])
+	if err != nil {
+		return biddingState{}, bidOutcome{}, err
+	}
+	leader, err := parseLeader(reply[5])
+	if err != nil {
+		return biddingState{}, bidOutcome{}, err
+	}
+
+	previous := biddingState{
+		highestBid: amounts[0],
+		leader:     previousLeader,
+		proxies:    map[uuid.UUID]domain.Amount{},
+	}
+	if amounts[1] > 0 {
+		previous.proxies[userID] = amounts[1]
+	}
+
+	outcome := bidOutcome{
+		price:   amounts[2],
+		leader:  leader,
+		proxies: map[uuid.UUID]domain.Amount{},
+	}
+	if amounts[3] > 0 {
+		outcome.proxies[userID] = amounts[3]
+	}
+
+	for i := 7; i < len(reply); i += 3 {
+		bidder, err := uuid.Parse(reply[i])
+		if err != nil {
+			return biddingState{}, bidOutcome{}, fmt.Errorf("invalid bidder in bid script reply: %w", err)
+		}
+		amount, err := parseAmount(reply[i+1])
+		if err != nil {
+			return biddingState{}, bidOutcome{}, fmt.Errorf("invalid amount in bid script reply: %w", err)
+		}
+		outcome.bids = append(outcome.bids, placedBid{userID: bidder, amount: amount, isAuto: reply[i+2] == "1"})
+	}
+
+	return previous, outcome, nil
+}
+
+// parseLeader parses a leader from the cache, where nobody leading is either
+// empty or the nil UUID.
+func parseLeader(leader string) (uuid.UUID, error) {
+	if leader == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(leader)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid highest bidder format: %w", err)
+	}
+	return id, nil
+}
+
+// formatAmount writes an amount for the cache, as a whole number of minor
+// units.
+func formatAmount(amount domain.Amount) string {
+	return strconv.FormatInt(int64(amount), 10)
+}
+
+// parseAmount reads an amount written by formatAmount.
+func parseAmount(amount string) (domain.Amount, error) {
+	units, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return domain.Amount(units), nil
+}
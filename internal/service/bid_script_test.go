@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to the Redis server in TEST_REDIS_URL, skipping the
+// test when it isn't set.
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	client := redis.NewClient(opt)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// TestAcceptBidScriptMatchesResolveBid runs every resolveBid case through
+// acceptBidScript, which has its own copy of the proxy rules, and checks
+// the two agree.
+func TestAcceptBidScriptMatchesResolveBid(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+	s := &BidService{cache: client}
+
+	increment := domain.Amount(100)
+
+	for _, tc := range resolveBidCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auction := &domain.Auction{
+				ID:           uuid.New(),
+				BidIncrement: &increment,
+				EndTime:      time.Now().Add(time.Hour),
+			}
+			keys := append(cache.AuctionKeys(auction.ID), cache.ClosedKey(auction.ID))
+			t.Cleanup(func() { client.Del(ctx, keys...) })
+
+			_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				writeBiddingState(ctx, pipe, auction.ID, tc.state.highestBid, tc.state.leader, tc.state.proxies)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("failed to cache bidding state: %v", err)
+			}
+
+			want := resolveBid(tc.state, tc.userID, tc.amount, tc.maxAmount, auction.MinimumIncrement)
+
+			_, got, err := s.acceptBid(ctx, auction, tc.userID, tc.amount, tc.maxAmount)
+			if err != nil {
+				t.Fatalf("acceptBid: %v", err)
+			}
+
+			if !reflect.DeepEqual(got.bids, want.bids) {
+				t.Errorf("script placed %+v, resolveBid placed %+v", got.bids, want.bids)
+			}
+			if got.price != want.price || got.leader != want.leader {
+				t.Errorf("script left %s leading at %s, resolveBid left %s leading at %s", got.leader, got.price, want.leader, want.price)
+			}
+
+			cached, err := client.HGetAll(ctx, cache.ProxyBidsKey(auction.ID)).Result()
+			if err != nil {
+				t.Fatalf("failed to read cached proxies: %v", err)
+			}
+			proxies := make(map[uuid.UUID]domain.Amount, len(cached))
+			for bidder, ceiling := range cached {
+				proxies[uuid.MustParse(bidder)], err = parseAmount(ceiling)
+				if err != nil {
+					t.Fatalf("invalid cached proxy ceiling %q: %v", ceiling, err)
+				}
+			}
+			if !maps.Equal(proxies, want.proxies) {
+				t.Errorf("script left proxies %v, resolveBid left %v", proxies, want.proxies)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aglili/auction-app/internal/cache"
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/aglili/auction-app/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// how long a cache that is ahead of the bids table is given to be caught up
+// by the bid being stored, before it is treated as a phantom bid
+const driftGrace = 5 * time.Second
+
+type cacheDrift int
+
+const (
+	cacheInSync cacheDrift = iota
+	cacheBehind
+	cacheAhead
+)
+
+// storedBiddingState derives the bidding state of an auction from the bids
+// and proxy_bids tables.
+func (s *BidService) storedBiddingState(ctx context.Context, auction *domain.Auction) (biddingState, error) {
+	bid, err := s.bidRepo.GetHighestBid(ctx, auction.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return stateFromBids(auction, nil, nil), nil
+	}
+	if err != nil {
+		return biddingState{}, fmt.Errorf("failed to load highest bid: %w", err)
+	}
+
+	proxies, err := s.bidRepo.GetProxyBids(ctx, auction.ID, bid.Amount)
+	if err != nil {
+		return biddingState{}, fmt.Errorf("failed to load proxy bids: %w", err)
+	}
+
+	return stateFromBids(auction, bid, proxies), nil
+}
+
+// stateFromBids builds the bidding state of an auction from its highest bid,
+// if any, and the proxies that can still bid above it.
+func stateFromBids(auction *domain.Auction, highest *domain.Bid, proxies []*domain.ProxyBid) biddingState {
+	state := biddingState{
+		highestBid: auction.StartingPrice,
+		proxies:    make(map[uuid.UUID]domain.Amount, len(proxies)),
+	}
+
+	if highest != nil {
+		state.highestBid = highest.Amount
+		state.leader = highest.UserID
+	}
+	for _, proxy := range proxies {
+		state.proxies[proxy.UserID] = proxy.MaxAmount
+	}
+
+	return state
+}
+
+// WarmBiddingState caches the bidding state of every open auction the cache
+// is missing, so bids after a Redis flush don't each go to Postgres.
+func (s *BidService) WarmBiddingState(ctx context.Context) error {
+	auctions, err := s.auctionRepo.GetActiveAuctions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch open auctions: %w", err)
+	}
+
+	warmed := 0
+	for _, auction := range auctions {
+		err := s.cache.Watch(ctx, func(tx *redis.Tx) error {
+			_, cached, err := readCachedState(ctx, tx, auction)
+			if err != nil || cached {
+				return err
+			}
+
+			state, err := s.storedBiddingState(ctx, auction)
+			if err != nil || state.leader == uuid.Nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				writeBiddingState(ctx, pipe, auction.ID, state.highestBid, state.leader, state.proxies)
+				return nil
+			})
+			if err == nil {
+				warmed++
+			}
+			return err
+		}, cache.AuctionKeys(auction.ID)...)
+
+		// a bid that got in first has already cached the state
+		if err != nil && err != redis.TxFailedErr {
+			log.Printf("Failed to warm bidding state for auction %s: %v", auction.ID, err)
+		}
+	}
+
+	log.Printf("Warmed bidding state for %d auctions", warmed)
+	return nil
+}
+
+// ReconcileBiddingState repairs open auctions whose cached bidding state or
+// current_price disagrees with the bids table. A cache that is ahead of the
+// table may just be a bid that hasn't been stored yet, so it is only
+// repaired if it is still ahead after driftGrace.
+func (s *BidService) ReconcileBiddingState(ctx context.Context) error {
+	auctions, err := s.auctionRepo.GetActiveAuctions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch open auctions: %w", err)
+	}
+
+	var ahead []*domain.Auction
+	for _, auction := range auctions {
+		synced, err := s.auctionRepo.SyncCurrentPrice(ctx, auction.ID)
+		if err != nil {
+			log.Printf("Failed to check current price of auction %s: %v", auction.ID, err)
+		} else if synced {
+			log.Printf("Corrected current price of auction %s", auction.ID)
+		}
+
+		drift, err := s.reconcileCachedState(ctx, auction, false)
+		if err != nil {
+			log.Printf("Failed to check bidding state of auction %s: %v", auction.ID, err)
+			continue
+		}
+		if drift == cacheAhead {
+			ahead = append(ahead, auction)
+		}
+	}
+
+	if len(ahead) == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(driftGrace):
+	}
+
+	for _, auction := range ahead {
+		if _, err := s.reconcileCachedState(ctx, auction, true); err != nil {
+			log.Printf("Failed to check bidding state of auction %s: %v", auction.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// reconcileCachedState compares the cached bidding state of an auction with
+// the stored one, and overwrites the cache when it is behind, or ahead and
+// repairAhead is set.
+func (s *BidService) reconcileCachedState(ctx context.Context, auction *domain.Auction, repairAhead bool) (cacheDrift, error) {
+	var drift cacheDrift
+
+	err := s.cache.Watch(ctx, func(tx *redis.Tx) error {
+		cached, found, err := readCachedState(ctx, tx, auction)
+		if err != nil {
+			return err
+		}
+
+		stored, err := s.storedBiddingState(ctx, auction)
+		if err != nil {
+			return err
+		}
+
+		drift = compareBiddingState(cached, found, stored)
+		if drift == cacheInSync || (drift == cacheAhead && !repairAhead) {
+			return nil
+		}
+
+		log.Printf("Repairing cached bidding state of auction %s: cached %s by %s, stored %s by %s",
+			auction.ID, cached.highestBid, cached.leader, stored.highestBid, stored.leader)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if stored.leader == uuid.Nil {
+				pipe.Del(ctx, cache.AuctionKeys(auction.ID)...)
+				return nil
+			}
+			writeBiddingState(ctx, pipe, auction.ID, stored.highestBid, stored.leader, stored.proxies)
+			return nil
+		})
+		return err
+	}, cache.AuctionKeys(auction.ID)...)
+
+	// a bid changed the state while it was being checked; the next pass
+	// looks again
+	if err == redis.TxFailedErr {
+		return cacheInSync, nil
+	}
+
+	return drift, err
+}
+
+func compareBiddingState(cached biddingState, found bool, stored biddingState) cacheDrift {
+	if !found {
+		if stored.leader == uuid.Nil {
+			return cacheInSync
+		}
+		return cacheBehind
+	}
+
+	switch {
+	case cached.highestBid < stored.highestBid:
+		return cacheBehind
+	case cached.highestBid > stored.highestBid:
+		return cacheAhead
+	}
+
+	if cached.leader != stored.leader || len(cached.proxies) != len(stored.proxies) {
+		return cacheBehind
+	}
+	for bidder, ceiling := range stored.proxies {
+		if cached.proxies[bidder] != ceiling {
+			return cacheBehind
+		}
+	}
+
+	return cacheInSync
+}
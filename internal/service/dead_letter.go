@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/aglili/auction-app/internal/events"
+	"github.com/aglili/auction-app/internal/repository"
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/google/uuid"
+)
+
+type DeadLetterService struct {
+	repository domain.DeadLetterRepository
+	publisher  *events.EventPublisher
+}
+
+func NewDeadLetterService(repository domain.DeadLetterRepository, publisher *events.EventPublisher) *DeadLetterService {
+	return &DeadLetterService{
+		repository: repository,
+		publisher:  publisher,
+	}
+}
+
+func (s *DeadLetterService) GetDeadLetters(ctx context.Context, page, limit int) ([]*domain.DeadLetter, int, error) {
+	deadLetters, total, err := s.repository.GetDeadLetters(ctx, page, limit)
+	if err != nil {
+		return nil, 0, utils.NewAppError(err, "failed to fetch dead letters", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	return deadLetters, total, nil
+}
+
+func (s *DeadLetterService) GetDeadLetter(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	deadLetter, err := s.repository.GetDeadLetter(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, utils.NewAppError(err, "dead letter not found", utils.ErrCodeNotFound, http.StatusNotFound)
+		}
+		return nil, utils.NewAppError(err, "failed to fetch dead letter", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	return deadLetter, nil
+}
+
+// ReplayDeadLetter publishes a dead-lettered event again under its original
+// ID, so it is handled as if delivered for the first time. If it fails
+// again it comes back as a new dead letter.
+func (s *DeadLetterService) ReplayDeadLetter(ctx context.Context, id uuid.UUID) (*domain.DeadLetter, error) {
+	deadLetter, err := s.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadLetter.ReplayedAt != nil {
+		return nil, utils.NewAppError(nil, "dead letter has already been replayed", utils.ErrCodeConflict, http.StatusConflict)
+	}
+
+	if err := s.publisher.Publish(ctx, deadLetter.EventID, deadLetter.Channel, []byte(deadLetter.Payload)); err != nil {
+		return nil, utils.NewAppError(err, "failed to replay dead letter", utils.ErrCodeInternal, http.StatusInternalServerError)
+	}
+
+	// a concurrent replay published it too, which the event ledger absorbs
+	if err := s.repository.MarkReplayed(ctx, id); err != nil && !errors.Is(err, repository.ErrConflict) {
+		return nil, utils.NewAppError(err, "failed to update dead letter", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	return s.GetDeadLetter(ctx, id)
+}
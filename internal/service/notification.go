@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/aglili/auction-app/internal/domain"
 	"github.com/aglili/auction-app/internal/websocket"
@@ -14,10 +15,10 @@ type NotificationService struct {
 	userRepo       domain.UserRepository
 	auctionRepo    domain.AuctionRepository
 	connManager    *websocket.ConnectionManager
-	paymentService *PaymentService
+	paymentService domain.PaymentService
 }
 
-func NewNotificationService(userRepo domain.UserRepository, auctionRepo domain.AuctionRepository, connManager *websocket.ConnectionManager, paymentService *PaymentService) *NotificationService {
+func NewNotificationService(userRepo domain.UserRepository, auctionRepo domain.AuctionRepository, connManager *websocket.ConnectionManager, paymentService domain.PaymentService) *NotificationService {
 	return &NotificationService{
 		userRepo:       userRepo,
 		connManager:    connManager,
@@ -26,7 +27,7 @@ func NewNotificationService(userRepo domain.UserRepository, auctionRepo domain.A
 	}
 }
 
-func (s *NotificationService) NotifyAuctionWon(ctx context.Context, userID, auctionID uuid.UUID, price float64) error {
+func (s *NotificationService) NotifyAuctionWon(ctx context.Context, userID, auctionID uuid.UUID, price domain.Amount) error {
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
@@ -37,9 +38,7 @@ func (s *NotificationService) NotifyAuctionWon(ctx context.Context, userID, auct
 		return fmt.Errorf("failed to get auction: %w", err)
 	}
 
-	reference := fmt.Sprintf("auction-won=%s", auctionID)
-
-	paymentData, err := s.paymentService.InitializePayment(ctx, user.Email, int64(price), reference)
+	payment, err := s.paymentService.StartPayment(ctx, auctionID, userID, user.Email, auction.Money(price))
 	if err != nil {
 		return fmt.Errorf("failed to initialize auction payment: %w", err)
 	}
@@ -51,8 +50,10 @@ func (s *NotificationService) NotifyAuctionWon(ctx context.Context, userID, auct
 			"title":        auction.Title,
 			"description":  auction.Description,
 			"price":        price,
-			"message":      fmt.Sprintf("Congratulations! You won the auction for $%.2f", price),
-			"payment_data": paymentData,
+			"currency":     auction.Currency,
+			"message":      fmt.Sprintf("Congratulations! You won the auction for %s", auction.Money(price)),
+			"payment_data": payment,
+			"pay_by":       payment.ExpiresAt,
 		},
 	}
 
@@ -64,7 +65,7 @@ func (s *NotificationService) NotifyAuctionWon(ctx context.Context, userID, auct
 	return nil
 }
 
-func (s *NotificationService) NotifyOutbid(ctx context.Context, userID, auctionID uuid.UUID, newPrice float64) error {
+func (s *NotificationService) NotifyOutbid(ctx context.Context, userID, auctionID uuid.UUID, newPrice domain.Amount) error {
 	_, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
@@ -81,7 +82,103 @@ func (s *NotificationService) NotifyOutbid(ctx context.Context, userID, auctionI
 			"auction_id": auctionID,
 			"title":      auction.Title,
 			"new_bid":    newPrice,
-			"message":    fmt.Sprintf("You've been outbid! Current bid is $%.2f", newPrice),
+			"currency":   auction.Currency,
+			"message":    fmt.Sprintf("You've been outbid! Current bid is %s", auction.Money(newPrice)),
+		},
+	}
+
+	if err := s.connManager.SendToUser(userID, message); err != nil {
+		log.Printf("Failed to send WebSocket notification: %v", err)
+	}
+
+	return nil
+}
+
+func (s *NotificationService) NotifyAuctionExtended(ctx context.Context, auctionID uuid.UUID, endTime time.Time) error {
+	message := websocket.NotificationMessage{
+		Type: "auction_extended",
+		Payload: map[string]any{
+			"auction_id": auctionID,
+			"end_time":   endTime,
+			"message":    "A late bid extended this auction",
+		},
+	}
+
+	s.connManager.Broadcast(message)
+
+	return nil
+}
+
+// NotifyPaymentCompleted tells the buyer their payment went through and the
+// seller that their auction has been paid for.
+func (s *NotificationService) NotifyPaymentCompleted(ctx context.Context, userID, auctionID uuid.UUID, amount domain.Amount) error {
+	auction, err := s.auctionRepo.GetAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction: %w", err)
+	}
+
+	buyerMessage := websocket.NotificationMessage{
+		Type: "payment_completed",
+		Payload: map[string]any{
+			"auction_id": auctionID,
+			"title":      auction.Title,
+			"amount":     amount,
+			"currency":   auction.Currency,
+			"message":    fmt.Sprintf("Your payment of %s was received", auction.Money(amount)),
+		},
+	}
+
+	if err := s.connManager.SendToUser(userID, buyerMessage); err != nil {
+		log.Printf("Failed to send WebSocket notification: %v", err)
+	}
+
+	sellerMessage := websocket.NotificationMessage{
+		Type: "auction_paid",
+		Payload: map[string]any{
+			"auction_id": auctionID,
+			"title":      auction.Title,
+			"amount":     amount,
+			"currency":   auction.Currency,
+			"message":    fmt.Sprintf("Your auction was paid for: %s", auction.Money(amount)),
+		},
+	}
+
+	if err := s.connManager.SendToUser(auction.SellerID, sellerMessage); err != nil {
+		log.Printf("Failed to send WebSocket notification: %v", err)
+	}
+
+	return nil
+}
+
+// NotifySecondChanceOffer asks a bidder to buy an auction whose winner never
+// paid, at amount, their highest bid.
+func (s *NotificationService) NotifySecondChanceOffer(ctx context.Context, userID, auctionID uuid.UUID, amount domain.Amount) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	auction, err := s.auctionRepo.GetAuction(ctx, auctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction: %w", err)
+	}
+
+	payment, err := s.paymentService.StartPayment(ctx, auctionID, userID, user.Email, auction.Money(amount))
+	if err != nil {
+		return fmt.Errorf("failed to initialize second chance payment: %w", err)
+	}
+
+	message := websocket.NotificationMessage{
+		Type: "second_chance_offer",
+		Payload: map[string]any{
+			"auction_id":   auction.ID,
+			"title":        auction.Title,
+			"description":  auction.Description,
+			"price":        amount,
+			"currency":     auction.Currency,
+			"message":      fmt.Sprintf("The winner didn't pay. You can still buy this item for your bid of %s", auction.Money(amount)),
+			"payment_data": payment,
+			"pay_by":       payment.ExpiresAt,
 		},
 	}
 
@@ -1,72 +1,328 @@
 package service
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/aglili/auction-app/internal/config"
 	"github.com/aglili/auction-app/internal/domain"
+	"github.com/aglili/auction-app/internal/events"
+	"github.com/aglili/auction-app/internal/payment"
+	"github.com/aglili/auction-app/internal/repository"
+	"github.com/aglili/auction-app/internal/utils"
+	"github.com/google/uuid"
 )
 
 type PaymentService struct {
-	cfg *config.Config
+	cfg         *config.Config
+	provider    domain.PaymentProvider
+	paymentRepo domain.PaymentRepository
+	bidRepo     domain.BidRepository
+	auctionRepo domain.AuctionRepository
 }
 
-func NewPaymentService(cfg *config.Config) *PaymentService {
-	return &PaymentService{cfg: cfg}
+func NewPaymentService(cfg *config.Config, provider domain.PaymentProvider, paymentRepo domain.PaymentRepository, bidRepo domain.BidRepository, auctionRepo domain.AuctionRepository) *PaymentService {
+	return &PaymentService{
+		cfg:         cfg,
+		provider:    provider,
+		paymentRepo: paymentRepo,
+		bidRepo:     bidRepo,
+		auctionRepo: auctionRepo,
+	}
+}
+
+// how many overdue payments are expired per pass
+const overduePaymentBatch = 100
+
+// StartPayment asks the winner of an auction to pay price for it within the
+// payment window. A payment still pending from an earlier attempt is
+// returned instead of starting another, so a retried notification doesn't
+// charge the winner twice.
+func (s *PaymentService) StartPayment(ctx context.Context, auctionID, userID uuid.UUID, email string, price domain.Money) (*domain.Payment, error) {
+	payment, err := s.paymentRepo.GetPendingPayment(ctx, auctionID, userID)
+	if err == nil {
+		return payment, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check pending payments: %w", err)
+	}
+
+	reference := fmt.Sprintf("auction-%s-%s", auctionID, uuid.NewString()[:8])
+
+	initialization, err := s.provider.Initialize(ctx, email, price, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	// stored only once the provider has accepted it; a reference it never
+	// hears about again is harmless since the winner is never sent it
+	payment = &domain.Payment{
+		AuctionID:        auctionID,
+		UserID:           userID,
+		Reference:        reference,
+		Amount:           price.Amount,
+		Currency:         price.Currency,
+		AuthorizationURL: initialization.AuthorizationURL,
+		ExpiresAt:        time.Now().Add(s.cfg.PaymentWindow),
+	}
+	if err := s.paymentRepo.CreatePayment(ctx, payment); err != nil {
+		return nil, fmt.Errorf("failed to store payment: %w", err)
+	}
+
+	return payment, nil
 }
 
-const BASE_URL = "https://api.paystack.co"
+// ConfirmPayment records a successful charge reported by the payment
+// provider, marks the auction paid and announces it. A charge for a
+// different amount or currency than was asked for is refunded and the
+// payment left pending, so the winner can still pay before its deadline and
+// the next bidder is offered the auction if they don't. A charge for a
+// payment that already failed or defaulted is refunded too. Charges already
+// recorded or refunded are ignored, since providers resend their events.
+func (s *PaymentService) ConfirmPayment(ctx context.Context, reference string, amount domain.Money, payload []byte) error {
+	payment, err := s.paymentRepo.GetPaymentByReference(ctx, reference)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return utils.NewAppError(err, "payment not found", utils.ErrCodeNotFound, http.StatusNotFound)
+		}
+		return utils.NewAppError(err, "failed to fetch payment", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	if payment.Status == domain.PaymentStatusDefaulted || payment.Status == domain.PaymentStatusFailed {
+		// the auction may already have gone to someone else, so the money
+		// goes back
+		log.Printf("Refunding charge for %s payment %s", payment.Status, reference)
+		return s.refundCharge(ctx, reference, amount)
+	}
 
-func (s *PaymentService) InitializePayment(ctx context.Context, email string, amount int64, reference string) (*domain.PaymentResponse, error) {
-	payload := domain.PaymentRequest{
-		Email:     email,
-		Amount:    amount,
+	if payment.Status != domain.PaymentStatusPending {
+		log.Printf("Ignoring repeated charge for payment %s", reference)
+		return nil
+	}
+
+	if amount != payment.Price() {
+		log.Printf("Refunding charge of %s for payment %s, which doesn't match its amount of %s", amount, reference, payment.Price())
+		return s.refundCharge(ctx, reference, amount)
+	}
+
+	completed, err := events.NewOutboxMessage(events.EventPaymentCompleted, events.PaymentCompletedEvent{
+		AuctionID: payment.AuctionID,
+		UserID:    payment.UserID,
 		Reference: reference,
-		Channels: []string{"card","bank_transfer","apple_pay","mobile_money","qr"},
+		Amount:    payment.Amount,
+		PaidAt:    time.Now(),
+	})
+	if err != nil {
+		return utils.NewAppError(err, "failed to update payment", utils.ErrCodeInternal, http.StatusInternalServerError)
 	}
 
-	jsonData, err := json.Marshal(payload)
+	err = s.paymentRepo.CompletePayment(ctx, reference, payload, completed)
+	if errors.Is(err, repository.ErrConflict) {
+		log.Printf("Ignoring repeated charge for payment %s", reference)
+		return nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return utils.NewAppError(err, "failed to update payment", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", BASE_URL+"/transaction/initialize", bytes.NewBuffer(jsonData))
+	return nil
+}
+
+// refundCharge refunds a charge on a payment that can't be accepted. The
+// refund is recorded on the payment first, so a charge the provider reports
+// again isn't refunded twice, and the record is dropped if the provider
+// doesn't make the refund.
+func (s *PaymentService) refundCharge(ctx context.Context, reference string, amount domain.Money) error {
+	err := s.paymentRepo.ClaimRefund(ctx, reference)
+	if errors.Is(err, repository.ErrConflict) {
+		log.Printf("Ignoring repeated charge for refunded payment %s", reference)
+		return nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return utils.NewAppError(err, "failed to update payment", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+s.cfg.PaystackSecretKey)
-	req.Header.Set("Content-Type", "applicatication/json")
+	if err := s.provider.Refund(ctx, reference, amount); err != nil {
+		if err := s.paymentRepo.ReleaseRefund(context.WithoutCancel(ctx), reference); err != nil {
+			log.Printf("Failed to release refund of payment %s: %v", reference, err)
+		}
+		return utils.NewAppError(err, "failed to refund payment", utils.ErrCodeInternal, http.StatusBadGateway)
+	}
+
+	return nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// HandleWebhook verifies a webhook from the payment provider and acts on
+// the event it reports. Events for payments the app doesn't know about are
+// ignored, so the provider stops resending them.
+func (s *PaymentService) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	event, err := s.provider.VerifyWebhook(header, body)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		return utils.NewAppError(err, "invalid signature", utils.ErrCodeUnauthorized, http.StatusUnauthorized)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return utils.NewAppError(err, "failed to parse webhook", utils.ErrCodeInvalidInput, http.StatusBadRequest)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	log.Printf("event of type:[%v] has been received", event.Type)
+
+	switch event.Type {
+	case domain.PaymentWebhookChargeSuccess:
+		err := s.ConfirmPayment(ctx, event.Reference, event.Amount, event.Payload)
+		if errors.Is(err, repository.ErrNotFound) {
+			log.Printf("Received charge for unknown payment %s", event.Reference)
+			return nil
+		}
+		return err
+	default:
+		log.Printf("Unhandled payment event: %s", event.Type)
+		return nil
+	}
+}
+
+// VerifyPayment checks a payment with the provider on behalf of the user
+// asked to make it, such as when they come back from checkout, so they
+// don't have to wait on the webhook. A successful charge is recorded just as
+// the webhook would record it. Only pending payments are checked; the rest
+// are already settled.
+func (s *PaymentService) VerifyPayment(ctx context.Context, reference string, userID uuid.UUID) (*domain.PaymentStatusResponse, error) {
+	stored, err := s.paymentRepo.GetPaymentByReference(ctx, reference)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, utils.NewAppError(err, "payment not found", utils.ErrCodeNotFound, http.StatusNotFound)
+		}
+		return nil, utils.NewAppError(err, "failed to fetch payment", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("paystack API error: %s - %s", resp.Status, string(body))
+	// other users are told nothing, not even that the payment exists
+	if stored.UserID != userID {
+		return nil, utils.NewAppError(nil, "payment not found", utils.ErrCodeNotFound, http.StatusNotFound)
 	}
 
-	paymentResponse := &domain.PaymentResponse{}
-	if err := json.Unmarshal(body, paymentResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	var providerStatus string
+	if stored.Status == domain.PaymentStatusPending {
+		providerStatus, err = s.reconcilePayment(ctx, stored)
+		if err != nil {
+			return nil, err
+		}
+
+		stored, err = s.paymentRepo.GetPaymentByReference(ctx, reference)
+		if err != nil {
+			return nil, utils.NewAppError(err, "failed to fetch payment", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+		}
+	}
+
+	auction, err := s.auctionRepo.GetAuction(ctx, stored.AuctionID)
+	if err != nil {
+		return nil, utils.NewAppError(err, "failed to fetch auction", utils.ErrCodeDatabaseError, http.StatusInternalServerError)
+	}
+
+	return &domain.PaymentStatusResponse{
+		Reference:      stored.Reference,
+		AuctionID:      stored.AuctionID,
+		Status:         stored.Status,
+		ProviderStatus: providerStatus,
+		AuctionStatus:  auction.Status,
+		Amount:         stored.Amount,
+		Currency:       stored.Currency,
+		PaidAt:         stored.PaidAt,
+		ExpiresAt:      stored.ExpiresAt,
+	}, nil
+}
+
+// reconcilePayment asks the provider about a pending payment and records a
+// successful charge. It returns the status the provider reported.
+//
+// A failed attempt leaves the payment pending, since the payer can try again
+// at checkout until the payment window closes, after which it defaults.
+func (s *PaymentService) reconcilePayment(ctx context.Context, pending *domain.Payment) (string, error) {
+	verification, err := s.provider.Verify(ctx, pending.Reference)
+	if errors.Is(err, payment.ErrUnknownReference) {
+		// nothing has been attempted yet
+		return domain.PaymentStatusPending, nil
+	}
+	if err != nil {
+		return "", utils.NewAppError(err, "failed to verify payment", utils.ErrCodeInternal, http.StatusBadGateway)
+	}
+
+	if verification.Status == domain.PaymentStatusSuccess {
+		if err := s.ConfirmPayment(ctx, pending.Reference, verification.Amount, verification.Payload); err != nil {
+			return "", err
+		}
+	}
+
+	return verification.Status, nil
+}
+
+// ExpireOverduePayments defaults payments whose window has passed and
+// offers each auction to its next highest bidder.
+func (s *PaymentService) ExpireOverduePayments(ctx context.Context) error {
+	payments, err := s.paymentRepo.GetOverduePayments(ctx, time.Now(), overduePaymentBatch)
+	if err != nil {
+		return fmt.Errorf("failed to fetch overdue payments: %w", err)
 	}
 
-	if !paymentResponse.Status {
-		return nil, fmt.Errorf("payment initialization failed: %s", paymentResponse.Message)
+	for _, payment := range payments {
+		if err := s.expirePayment(ctx, payment); err != nil {
+			log.Printf("Failed to expire payment %s: %v", payment.Reference, err)
+		}
 	}
 
-	return paymentResponse, nil
+	return nil
+}
+
+// expirePayment defaults an overdue payment and offers the auction to the
+// highest bidder not yet asked to pay, at their highest bid. When nobody is
+// left whose bid meets the reserve, the auction is left unpaid.
+func (s *PaymentService) expirePayment(ctx context.Context, payment *domain.Payment) error {
+	auction, err := s.auctionRepo.GetAuction(ctx, payment.AuctionID)
+	if err != nil {
+		return fmt.Errorf("failed to get auction: %w", err)
+	}
+
+	next, err := s.bidRepo.GetNextBidder(ctx, auction.ID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return fmt.Errorf("failed to find next bidder: %w", err)
+	}
+
+	var outbox []*domain.OutboxMessage
+	offered := auction.Status == domain.AuctionStatusClosed && next != nil && auction.ReserveMet(next.Amount)
+	if offered {
+		offer, err := events.NewOutboxMessage(events.EventSecondChanceOffer, events.SecondChanceOfferEvent{
+			AuctionID: auction.ID,
+			UserID:    next.UserID,
+			Amount:    next.Amount,
+			OfferedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		outbox = append(outbox, offer)
+	}
+
+	err = s.paymentRepo.DefaultPayment(ctx, payment.Reference, outbox...)
+	if errors.Is(err, repository.ErrConflict) {
+		// paid at the last moment, or expired by another replica
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to default payment: %w", err)
+	}
+
+	if offered {
+		log.Printf("Payment %s defaulted, offering auction %s to bidder %s", payment.Reference, auction.ID, next.UserID)
+		return nil
+	}
+
+	log.Printf("Payment %s defaulted with nobody left to offer auction %s to", payment.Reference, auction.ID)
+
+	// an auction that isn't waiting on this payment keeps its status
+	if auction.Status != domain.AuctionStatusClosed {
+		return nil
+	}
+	return s.auctionRepo.UpdateAuctionStatus(ctx, auction.ID, domain.AuctionStatusUnpaid)
 }
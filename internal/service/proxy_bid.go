@@ -0,0 +1,95 @@
+package service
+
+import (
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+// biddingState is the live state of an auction held in the cache.
+type biddingState struct {
+	highestBid domain.Amount
+	leader     uuid.UUID // uuid.Nil when nobody has bid yet
+	proxies    map[uuid.UUID]domain.Amount
+}
+
+type placedBid struct {
+	userID uuid.UUID
+	amount domain.Amount
+	isAuto bool
+}
+
+// bidOutcome is the state of an auction once a bid and every proxy bid it
+// triggered have been applied.
+type bidOutcome struct {
+	bids    []placedBid
+	price   domain.Amount
+	leader  uuid.UUID
+	proxies map[uuid.UUID]domain.Amount
+}
+
+// resolveBid applies a bid of amount, with an optional proxy ceiling of
+// maxAmount, against the current state of an auction. increment gives the
+// minimum raise over a price.
+//
+// Only the current leader can hold a proxy above the highest bid, since any
+// other proxy would already have responded, so a bid is only ever contested
+// by two parties. Whoever has the higher ceiling keeps or takes the lead at
+// one increment above the other's ceiling. When the ceilings are equal the
+// earlier proxy wins. Every bid placed along the way, manual or automatic,
+// is returned in the order it was placed so amounts are strictly increasing.
+func resolveBid(state biddingState, userID uuid.UUID, amount, maxAmount domain.Amount, increment func(price domain.Amount) domain.Amount) bidOutcome {
+	proxies := make(map[uuid.UUID]domain.Amount, len(state.proxies)+1)
+	for bidder, ceiling := range state.proxies {
+		proxies[bidder] = ceiling
+	}
+
+	if maxAmount > amount && maxAmount > proxies[userID] {
+		proxies[userID] = maxAmount
+	}
+
+	outcome := bidOutcome{
+		bids:    []placedBid{{userID: userID, amount: amount}},
+		price:   amount,
+		leader:  userID,
+		proxies: proxies,
+	}
+
+	defender := state.leader
+	if defender == uuid.Nil || defender == userID {
+		return outcome.dropExhausted()
+	}
+
+	ceiling := max(amount, proxies[userID])
+	defenderCeiling := proxies[defender]
+
+	// the defender's proxy can't even answer the opening bid
+	if defenderCeiling <= amount {
+		return outcome.dropExhausted()
+	}
+
+	if defenderCeiling >= ceiling {
+		if ceiling > amount && ceiling < defenderCeiling {
+			outcome.bids = append(outcome.bids, placedBid{userID: userID, amount: ceiling, isAuto: true})
+		}
+
+		outcome.price = min(defenderCeiling, ceiling+increment(ceiling))
+		outcome.leader = defender
+		outcome.bids = append(outcome.bids, placedBid{userID: defender, amount: outcome.price, isAuto: true})
+		return outcome.dropExhausted()
+	}
+
+	outcome.bids = append(outcome.bids, placedBid{userID: defender, amount: defenderCeiling, isAuto: true})
+	outcome.price = min(ceiling, defenderCeiling+increment(defenderCeiling))
+	outcome.bids = append(outcome.bids, placedBid{userID: userID, amount: outcome.price, isAuto: true})
+	return outcome.dropExhausted()
+}
+
+// dropExhausted removes proxies that can no longer bid above the price.
+func (o bidOutcome) dropExhausted() bidOutcome {
+	for bidder, ceiling := range o.proxies {
+		if ceiling <= o.price {
+			delete(o.proxies, bidder)
+		}
+	}
+	return o
+}
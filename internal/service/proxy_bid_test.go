@@ -0,0 +1,215 @@
+package service
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+
+	"github.com/aglili/auction-app/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	defender   = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	challenger = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+)
+
+// flatIncrement raises any price by 1.00.
+func flatIncrement(domain.Amount) domain.Amount { return 100 }
+
+type resolveBidCase struct {
+	name      string
+	state     biddingState
+	userID    uuid.UUID
+	amount    domain.Amount
+	maxAmount domain.Amount
+
+	bids    []placedBid
+	price   domain.Amount
+	leader  uuid.UUID
+	proxies map[uuid.UUID]domain.Amount
+}
+
+// ledBy is the state of an auction defender leads at highestBid, with
+// proxies as its live proxy bids.
+func ledBy(highestBid domain.Amount, proxies map[uuid.UUID]domain.Amount) biddingState {
+	return biddingState{highestBid: highestBid, leader: defender, proxies: proxies}
+}
+
+var resolveBidCases = []resolveBidCase{
+	{
+		name:   "first bid",
+		userID: challenger, amount: 1000,
+		bids:    []placedBid{{userID: challenger, amount: 1000}},
+		price:   1000,
+		leader:  challenger,
+		proxies: map[uuid.UUID]domain.Amount{},
+	},
+	{
+		name:   "first bid with a proxy",
+		userID: challenger, amount: 1000, maxAmount: 2000,
+		bids:    []placedBid{{userID: challenger, amount: 1000}},
+		price:   1000,
+		leader:  challenger,
+		proxies: map[uuid.UUID]domain.Amount{challenger: 2000},
+	},
+	{
+		name:   "leader raises their proxy",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 1500}),
+		userID: defender, amount: 1100, maxAmount: 3000,
+		bids:    []placedBid{{userID: defender, amount: 1100}},
+		price:   1100,
+		leader:  defender,
+		proxies: map[uuid.UUID]domain.Amount{defender: 3000},
+	},
+	{
+		name:   "leader keeps a higher proxy than their new one",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 3000}),
+		userID: defender, amount: 1100, maxAmount: 2000,
+		bids:    []placedBid{{userID: defender, amount: 1100}},
+		price:   1100,
+		leader:  defender,
+		proxies: map[uuid.UUID]domain.Amount{defender: 3000},
+	},
+	{
+		name:   "defender without a proxy",
+		state:  ledBy(1000, nil),
+		userID: challenger, amount: 1100,
+		bids:    []placedBid{{userID: challenger, amount: 1100}},
+		price:   1100,
+		leader:  challenger,
+		proxies: map[uuid.UUID]domain.Amount{},
+	},
+	{
+		name:   "defender's ceiling can't answer the opening bid",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 1200}),
+		userID: challenger, amount: 1200,
+		bids:    []placedBid{{userID: challenger, amount: 1200}},
+		price:   1200,
+		leader:  challenger,
+		proxies: map[uuid.UUID]domain.Amount{},
+	},
+	{
+		name:   "defender answers a manual bid",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 2000}),
+		userID: challenger, amount: 1100,
+		bids: []placedBid{
+			{userID: challenger, amount: 1100},
+			{userID: defender, amount: 1200, isAuto: true},
+		},
+		price:   1200,
+		leader:  defender,
+		proxies: map[uuid.UUID]domain.Amount{defender: 2000},
+	},
+	{
+		name:   "defender's answer is capped at its ceiling",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 1150}),
+		userID: challenger, amount: 1100,
+		bids: []placedBid{
+			{userID: challenger, amount: 1100},
+			{userID: defender, amount: 1150, isAuto: true},
+		},
+		price:   1150,
+		leader:  defender,
+		proxies: map[uuid.UUID]domain.Amount{},
+	},
+	{
+		name:   "challenger auto-bids its ceiling before the defender answers",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 2000}),
+		userID: challenger, amount: 1100, maxAmount: 1500,
+		bids: []placedBid{
+			{userID: challenger, amount: 1100},
+			{userID: challenger, amount: 1500, isAuto: true},
+			{userID: defender, amount: 1600, isAuto: true},
+		},
+		price:   1600,
+		leader:  defender,
+		proxies: map[uuid.UUID]domain.Amount{defender: 2000},
+	},
+	{
+		name:   "equal ceilings go to the earlier proxy",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 2000}),
+		userID: challenger, amount: 1100, maxAmount: 2000,
+		bids: []placedBid{
+			{userID: challenger, amount: 1100},
+			{userID: defender, amount: 2000, isAuto: true},
+		},
+		price:   2000,
+		leader:  defender,
+		proxies: map[uuid.UUID]domain.Amount{},
+	},
+	{
+		name:   "challenger's higher ceiling takes the lead",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 1500}),
+		userID: challenger, amount: 1100, maxAmount: 3000,
+		bids: []placedBid{
+			{userID: challenger, amount: 1100},
+			{userID: defender, amount: 1500, isAuto: true},
+			{userID: challenger, amount: 1600, isAuto: true},
+		},
+		price:   1600,
+		leader:  challenger,
+		proxies: map[uuid.UUID]domain.Amount{challenger: 3000},
+	},
+	{
+		name:   "challenger takes the lead at its ceiling",
+		state:  ledBy(1000, map[uuid.UUID]domain.Amount{defender: 1500}),
+		userID: challenger, amount: 1100, maxAmount: 1550,
+		bids: []placedBid{
+			{userID: challenger, amount: 1100},
+			{userID: defender, amount: 1500, isAuto: true},
+			{userID: challenger, amount: 1550, isAuto: true},
+		},
+		price:   1550,
+		leader:  challenger,
+		proxies: map[uuid.UUID]domain.Amount{},
+	},
+}
+
+func TestResolveBid(t *testing.T) {
+	for _, tc := range resolveBidCases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := maps.Clone(tc.state.proxies)
+
+			outcome := resolveBid(tc.state, tc.userID, tc.amount, tc.maxAmount, flatIncrement)
+
+			if !reflect.DeepEqual(outcome.bids, tc.bids) {
+				t.Errorf("bids = %+v, want %+v", outcome.bids, tc.bids)
+			}
+			if outcome.price != tc.price {
+				t.Errorf("price = %s, want %s", outcome.price, tc.price)
+			}
+			if outcome.leader != tc.leader {
+				t.Errorf("leader = %s, want %s", outcome.leader, tc.leader)
+			}
+			if !maps.Equal(outcome.proxies, tc.proxies) {
+				t.Errorf("proxies = %v, want %v", outcome.proxies, tc.proxies)
+			}
+
+			for i := 1; i < len(outcome.bids); i++ {
+				if outcome.bids[i].amount <= outcome.bids[i-1].amount {
+					t.Errorf("bid %d of %s doesn't raise the previous bid of %s", i, outcome.bids[i].amount, outcome.bids[i-1].amount)
+				}
+			}
+
+			if !maps.Equal(tc.state.proxies, before) {
+				t.Errorf("resolveBid changed the proxies it was given to %v", tc.state.proxies)
+			}
+		})
+	}
+}
+
+func TestDropExhausted(t *testing.T) {
+	third := uuid.New()
+	outcome := bidOutcome{
+		price:   1000,
+		proxies: map[uuid.UUID]domain.Amount{defender: 1000, challenger: 1500, third: 900},
+	}
+
+	got := outcome.dropExhausted().proxies
+
+	want := map[uuid.UUID]domain.Amount{challenger: 1500}
+	if !maps.Equal(got, want) {
+		t.Fatalf("proxies = %v, want %v", got, want)
+	}
+}
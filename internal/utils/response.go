@@ -178,3 +178,24 @@ func PaginatedResponse(message string, data interface{}, page, limit, total int)
 		},
 	}
 }
+
+type CursorMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+func CursorPaginatedResponse(message string, data interface{}, limit int, nextCursor string) APIResponse {
+	return APIResponse{
+		Success: true,
+		Message: message,
+		Data: map[string]interface{}{
+			"items": data,
+			"meta": CursorMeta{
+				Limit:      limit,
+				NextCursor: nextCursor,
+				HasMore:    nextCursor != "",
+			},
+		},
+	}
+}
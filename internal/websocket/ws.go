@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -23,29 +24,48 @@ type NotificationMessage struct {
 	Payload any    `json:"payload"`
 }
 
+// how long a write to a client may take before the client is considered
+// too slow and the write fails
+const writeWait = 10 * time.Second
+
+// client is a connected user. gorilla/websocket allows one writer per
+// connection at a time, so every write goes through write.
+type client struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *client) write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
 type ConnectionManager struct {
-	connections map[uuid.UUID]*websocket.Conn
+	connections map[uuid.UUID]*client
 	sync.RWMutex
 }
 
 func NewConnectionManager() *ConnectionManager {
 	return &ConnectionManager{
-		connections: make(map[uuid.UUID]*websocket.Conn),
+		connections: make(map[uuid.UUID]*client),
 	}
 }
 
 func (cm *ConnectionManager) Register(userID uuid.UUID, conn *websocket.Conn) {
 	cm.Lock()
 	defer cm.Unlock()
-	cm.connections[userID] = conn
+	cm.connections[userID] = &client{conn: conn}
 	log.Printf("User %s connected via WebSocket", userID)
 }
 
 func (cm *ConnectionManager) UnRegister(userID uuid.UUID) {
 	cm.Lock()
 	defer cm.Unlock()
-	if conn, exists := cm.connections[userID]; exists {
-		conn.Close()
+	if c, exists := cm.connections[userID]; exists {
+		c.conn.Close()
 		delete(cm.connections, userID)
 		log.Printf("User %s disconnected from WebSocket", userID)
 	}
@@ -53,7 +73,7 @@ func (cm *ConnectionManager) UnRegister(userID uuid.UUID) {
 
 func (cm *ConnectionManager) SendToUser(userID uuid.UUID, message NotificationMessage) error {
 	cm.RLock()
-	conn, exists := cm.connections[userID]
+	c, exists := cm.connections[userID]
 	cm.RUnlock()
 
 	if !exists {
@@ -65,6 +85,47 @@ func (cm *ConnectionManager) SendToUser(userID uuid.UUID, message NotificationMe
 		return err
 	}
 
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return c.write(data)
+}
+
+func (cm *ConnectionManager) Broadcast(message NotificationMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Failed to marshal broadcast message: %v", err)
+		return
+	}
+
+	// written outside the lock so a slow client doesn't hold up others
+	// connecting or disconnecting
+	cm.RLock()
+	clients := make(map[uuid.UUID]*client, len(cm.connections))
+	for userID, c := range cm.connections {
+		clients[userID] = c
+	}
+	cm.RUnlock()
+
+	for userID, c := range clients {
+		if err := c.write(data); err != nil {
+			log.Printf("Failed to broadcast to user %s: %v", userID, err)
+		}
+	}
+}
 
+// CloseAll sends every client a close frame saying the server is going away
+// and drops its connection.
+func (cm *ConnectionManager) CloseAll() {
+	cm.Lock()
+	defer cm.Unlock()
+
+	message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	deadline := time.Now().Add(time.Second)
+
+	// WriteControl may run alongside a write in progress
+	for userID, c := range cm.connections {
+		if err := c.conn.WriteControl(websocket.CloseMessage, message, deadline); err != nil {
+			log.Printf("Failed to send close frame to user %s: %v", userID, err)
+		}
+		c.conn.Close()
+		delete(cm.connections, userID)
+	}
 }
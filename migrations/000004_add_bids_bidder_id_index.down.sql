@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_bids_bidder_id;
@@ -0,0 +1 @@
+CREATE INDEX idx_bids_bidder_id ON bids (bidder_id, auction_id);
@@ -0,0 +1,11 @@
+ALTER TABLE bids ADD COLUMN is_auto BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE proxy_bids(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    auction_id UUID NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+    bidder_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    max_amount NUMERIC(12,2) NOT NULL CHECK (max_amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_proxy_bid_per_bidder UNIQUE (auction_id, bidder_id)
+);
@@ -0,0 +1,2 @@
+-- NULL means the auction uses the default tiered increments
+ALTER TABLE auctions ADD COLUMN bid_increment NUMERIC(12,2) CHECK (bid_increment > 0);
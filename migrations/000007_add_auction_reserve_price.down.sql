@@ -0,0 +1,7 @@
+UPDATE auctions SET status = 'closed' WHERE status = 'reserve_not_met';
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closed', 'cancelled'));
+
+ALTER TABLE auctions DROP COLUMN IF EXISTS reserve_price;
@@ -0,0 +1,5 @@
+ALTER TABLE auctions ADD COLUMN reserve_price NUMERIC(12,2) CHECK (reserve_price > 0);
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closed', 'cancelled', 'reserve_not_met'));
@@ -0,0 +1 @@
+ALTER TABLE auctions ADD COLUMN buy_now_price NUMERIC(12,2) CHECK (buy_now_price > 0);
@@ -0,0 +1,3 @@
+ALTER TABLE auctions
+    DROP COLUMN IF EXISTS soft_close_window_minutes,
+    DROP COLUMN IF EXISTS soft_close_extension_minutes;
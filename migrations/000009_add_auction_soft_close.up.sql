@@ -0,0 +1,4 @@
+-- a bid landing in the last soft_close_window_minutes pushes end_time back by soft_close_extension_minutes
+ALTER TABLE auctions
+    ADD COLUMN soft_close_window_minutes INT NOT NULL DEFAULT 0 CHECK (soft_close_window_minutes >= 0),
+    ADD COLUMN soft_close_extension_minutes INT NOT NULL DEFAULT 0 CHECK (soft_close_extension_minutes >= 0);
@@ -0,0 +1,7 @@
+UPDATE auctions SET status = 'open' WHERE status = 'closing';
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closed', 'cancelled', 'reserve_not_met'));
+
+ALTER TABLE auctions DROP COLUMN IF EXISTS closing_started_at;
@@ -0,0 +1,7 @@
+-- 'closing' marks an auction claimed by one scheduler replica; the claim
+-- expires if that replica dies before finishing
+ALTER TABLE auctions ADD COLUMN closing_started_at TIMESTAMPTZ;
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closing', 'closed', 'cancelled', 'reserve_not_met'));
@@ -0,0 +1,13 @@
+CREATE TABLE dead_letters(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id VARCHAR(100) NOT NULL,
+    channel VARCHAR(100) NOT NULL,
+    -- kept as text since an event may be dead-lettered for being malformed
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_dead_letters_created_at ON dead_letters (created_at DESC);
@@ -0,0 +1,7 @@
+UPDATE auctions SET status = 'closed' WHERE status = 'paid';
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closing', 'closed', 'cancelled', 'reserve_not_met'));
+
+DROP TABLE IF EXISTS payments;
@@ -0,0 +1,24 @@
+CREATE TABLE payments(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    auction_id UUID NOT NULL REFERENCES auctions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reference VARCHAR(100) NOT NULL UNIQUE,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'success', 'failed')),
+    authorization_url TEXT,
+    -- the last event the payment provider reported for this payment
+    provider_payload JSONB,
+    paid_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_payments_auction_id_user_id ON payments (auction_id, user_id);
+
+-- an auction is only ever paid for once
+CREATE UNIQUE INDEX idx_payments_auction_id_success ON payments (auction_id) WHERE status = 'success';
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closing', 'closed', 'paid', 'cancelled', 'reserve_not_met'));
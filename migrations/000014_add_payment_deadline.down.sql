@@ -0,0 +1,14 @@
+UPDATE auctions SET status = 'closed' WHERE status = 'unpaid';
+
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closing', 'closed', 'paid', 'cancelled', 'reserve_not_met'));
+
+UPDATE payments SET status = 'failed' WHERE status = 'defaulted';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'success', 'failed'));
+
+DROP INDEX IF EXISTS idx_payments_pending_expires_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,16 @@
+-- a winner who hasn't paid by expires_at defaults, and the auction is
+-- offered to the next highest bidder
+ALTER TABLE payments ADD COLUMN expires_at TIMESTAMPTZ;
+UPDATE payments SET expires_at = created_at + INTERVAL '48 hours';
+ALTER TABLE payments ALTER COLUMN expires_at SET NOT NULL;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'success', 'failed', 'defaulted'));
+
+CREATE INDEX idx_payments_pending_expires_at ON payments (expires_at) WHERE status = 'pending';
+
+-- 'unpaid' is a closed auction that every eligible bidder defaulted on
+ALTER TABLE auctions DROP CONSTRAINT IF EXISTS auctions_status_check;
+ALTER TABLE auctions ADD CONSTRAINT auctions_status_check
+    CHECK (status IN ('open', 'closing', 'closed', 'paid', 'unpaid', 'cancelled', 'reserve_not_met'));
@@ -0,0 +1,13 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE auctions DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE payments ALTER COLUMN amount TYPE NUMERIC(12,2) USING amount / 100.0;
+ALTER TABLE proxy_bids ALTER COLUMN max_amount TYPE NUMERIC(12,2) USING max_amount / 100.0;
+ALTER TABLE bids ALTER COLUMN amount TYPE NUMERIC(12,2) USING amount / 100.0;
+
+ALTER TABLE auctions
+    ALTER COLUMN starting_price TYPE NUMERIC(12,2) USING starting_price / 100.0,
+    ALTER COLUMN current_price TYPE NUMERIC(12,2) USING current_price / 100.0,
+    ALTER COLUMN bid_increment TYPE NUMERIC(12,2) USING bid_increment / 100.0,
+    ALTER COLUMN reserve_price TYPE NUMERIC(12,2) USING reserve_price / 100.0,
+    ALTER COLUMN buy_now_price TYPE NUMERIC(12,2) USING buy_now_price / 100.0;
@@ -0,0 +1,18 @@
+-- amounts are stored as whole minor units of the auction's currency, such
+-- as pesewas or kobo, so they are never rounded on their way through the app
+ALTER TABLE auctions
+    ALTER COLUMN starting_price TYPE BIGINT USING ROUND(starting_price * 100)::BIGINT,
+    ALTER COLUMN current_price TYPE BIGINT USING ROUND(current_price * 100)::BIGINT,
+    ALTER COLUMN bid_increment TYPE BIGINT USING ROUND(bid_increment * 100)::BIGINT,
+    ALTER COLUMN reserve_price TYPE BIGINT USING ROUND(reserve_price * 100)::BIGINT,
+    ALTER COLUMN buy_now_price TYPE BIGINT USING ROUND(buy_now_price * 100)::BIGINT;
+
+ALTER TABLE bids ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE proxy_bids ALTER COLUMN max_amount TYPE BIGINT USING ROUND(max_amount * 100)::BIGINT;
+ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+
+-- ISO 4217 code; existing auctions were all held in cedis
+ALTER TABLE auctions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'GHS';
+ALTER TABLE payments ADD COLUMN currency CHAR(3);
+UPDATE payments p SET currency = a.currency FROM auctions a WHERE a.id = p.auction_id;
+ALTER TABLE payments ALTER COLUMN currency SET NOT NULL;
@@ -0,0 +1,3 @@
+-- identifies the claim on a 'closing' auction, so a replica whose claim
+-- expired and was taken over can't finish closing it as well
+ALTER TABLE auctions ADD COLUMN closing_token UUID;
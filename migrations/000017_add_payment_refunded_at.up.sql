@@ -0,0 +1,3 @@
+-- set once a charge on the payment has been refunded, so a charge the
+-- provider reports again isn't refunded twice
+ALTER TABLE payments ADD COLUMN refunded_at TIMESTAMP;
@@ -5,3 +5,15 @@ const (
 	DEVELOPMENT = "development"
 	STAGING     = "staging"
 )
+
+// how bids are validated and stored, set by BID_PLACEMENT_MODE
+const (
+	BID_PLACEMENT_REDIS    = "redis"
+	BID_PLACEMENT_POSTGRES = "postgres"
+)
+
+// who takes payments, set by PAYMENT_PROVIDER
+const (
+	PAYMENT_PROVIDER_PAYSTACK = "paystack"
+	PAYMENT_PROVIDER_FAKE     = "fake"
+)